@@ -16,7 +16,9 @@ import (
 
 // Start the Datadog integration. It is the caller's responsibility to call the
 // returned StopFunc to stop the Datadog integration. When calling the StopFunc
-// function traces and metrics will be flushed, and profiling will be stopped.
+// function traces and metrics will be flushed, gauges registered with
+// metrics.RegisterGaugeFunc will be polled a final time, and profiling will be
+// stopped.
 //
 // Canceling the supplied context.Context will not trigger the returned
 // StopFunc, since that could lead to loss of important traces or metrics.
@@ -146,7 +148,7 @@ func stop(options *options) error {
 	go func() {
 		tracer.Stop()
 		profiler.Stop()
-		err := metrics.Flush()
+		err := metrics.GlobalShutdown()
 		errCh <- err
 	}()
 
@@ -4,10 +4,14 @@ import (
 	"context"
 	"testing"
 
+	"github.com/DataDog/dd-trace-go/v2/ddtrace/tracer"
 	coopdatadog "github.com/coopnorge/go-datadog-lib/v2"
+	"github.com/coopnorge/go-datadog-lib/v2/ddtest"
 	"github.com/coopnorge/go-datadog-lib/v2/internal"
 	"github.com/coopnorge/go-datadog-lib/v2/internal/testhelpers"
+	"github.com/coopnorge/go-datadog-lib/v2/metrics"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestBootstrapDatadogDisabled(t *testing.T) {
@@ -47,3 +51,41 @@ func TestBootstrapMissingEnvVar(t *testing.T) {
 	assert.ErrorContains(t, err, "required environmental variable not set: ")
 	assert.NotNil(t, stop)
 }
+
+func TestBootstrapSendsToAgent(t *testing.T) {
+	agent := ddtest.StartAgent(t)
+
+	stop, err := coopdatadog.Start(context.Background())
+	require.NoError(t, err)
+
+	span := tracer.StartSpan("bootstrap.test", tracer.ResourceName("/bootstrap"))
+	span.Finish()
+
+	err = stop()
+	require.NoError(t, err)
+
+	got := agent.RequireSpan(t, "bootstrap.test")
+	assert.Equal(t, "/bootstrap", got.Resource)
+	assert.Equal(t, "unittest-service", got.Service)
+}
+
+func TestBootstrapSendsMetricsToAgent(t *testing.T) {
+	// coopdatadog.Start only configures the metrics once per process, so
+	// every agent must receive the metrics sent after it is started.
+	for _, name := range []string{"first", "second"} {
+		t.Run(name, func(t *testing.T) {
+			agent := ddtest.StartAgent(t)
+
+			stop, err := coopdatadog.Start(context.Background())
+			require.NoError(t, err)
+
+			metrics.Incr("bootstrap.counter", metrics.WithTag("agent", name))
+
+			err = stop()
+			require.NoError(t, err)
+
+			got := agent.RequireMetric(t, "bootstrap.counter")
+			assert.True(t, got.HasTag("agent:"+name))
+		})
+	}
+}
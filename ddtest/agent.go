@@ -0,0 +1,229 @@
+// Package ddtest implements an in-process stand-in for the Datadog Agent,
+// intended for verifying traces and metrics in tests.
+package ddtest
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/coopnorge/go-datadog-lib/v2/internal"
+	"github.com/coopnorge/go-datadog-lib/v2/internal/seam"
+	// Imported to ensure that the metrics package has set up the seam.
+	_ "github.com/coopnorge/go-datadog-lib/v2/metrics"
+)
+
+const (
+	defaultWaitTimeout = 5 * time.Second
+	pollInterval       = 10 * time.Millisecond
+)
+
+// Agent is a fake Datadog Agent that records the traces and DogStatsD
+// datagrams sent to it. Create it with StartAgent.
+type Agent struct {
+	cfg *config
+
+	apmListener net.Listener
+	apmServer   *http.Server
+	dsdConn     net.PacketConn
+
+	apmEndpoint string
+	dsdEndpoint string
+	tempDir     string
+
+	mu            sync.Mutex
+	spans         []Span
+	metrics       []Metric
+	events        []Event
+	serviceChecks []ServiceCheck
+	errs          []error
+
+	wg sync.WaitGroup
+}
+
+// StartAgent starts a fake Datadog Agent and points the environment variables
+// read by coopdatadog.Start at it. By default both traces and DogStatsD
+// datagrams are received on Unix sockets in a temporary directory. The
+// package-level functions of the metrics package are also pointed at the
+// agent, since coopdatadog.Start only configures them once per process. The
+// agent is stopped, and the environment and the metrics restored, by
+// t.Cleanup.
+//
+// Since the environment is modified with t.Setenv, StartAgent cannot be used
+// in parallel tests.
+func StartAgent(t testing.TB, options ...Option) *Agent {
+	t.Helper()
+
+	cfg := defaults()
+	for _, opt := range options {
+		opt(cfg)
+	}
+
+	a := &Agent{cfg: cfg}
+	err := a.start()
+	if err != nil {
+		a.stop()
+		t.Fatalf("failed to start fake Datadog Agent: %v", err)
+	}
+	t.Cleanup(func() {
+		a.stop()
+		for _, err := range a.errs {
+			t.Errorf("fake Datadog Agent: %v", err)
+		}
+	})
+
+	t.Setenv(internal.DatadogDisable, "false")
+	t.Setenv(internal.DatadogAPMEndpoint, a.apmEndpoint)
+	t.Setenv(internal.DatadogDSDEndpoint, a.dsdEndpoint)
+	setenvIfUnset(t, internal.DatadogEnvironment, cfg.env)
+	setenvIfUnset(t, internal.DatadogService, cfg.service)
+	setenvIfUnset(t, internal.DatadogVersion, cfg.version)
+	// Disable features that talk to the agent in the background, as the fake
+	// agent does not implement them.
+	t.Setenv("DD_INSTRUMENTATION_TELEMETRY_ENABLED", "false")
+	t.Setenv("DD_REMOTE_CONFIGURATION_ENABLED", "false")
+
+	// metrics.GlobalSetup only configures the package-level functions once
+	// per process, so they are pointed at this agent directly, for every test
+	// that starts an agent.
+	restore, err := seam.SetupDefaultClient()
+	if err != nil {
+		t.Fatalf("failed to point metrics at fake Datadog Agent: %v", err)
+	}
+	t.Cleanup(restore)
+
+	return a
+}
+
+func setenvIfUnset(t testing.TB, key, value string) {
+	if v, ok := os.LookupEnv(key); ok && v != "" {
+		return
+	}
+	t.Setenv(key, value)
+}
+
+func (a *Agent) start() error {
+	var dir string
+	if !a.cfg.apmHTTP || !a.cfg.dsdUDP {
+		// Unix socket paths are limited to ~100 characters, so t.TempDir()
+		// cannot be used as it includes the name of the test.
+		var err error
+		dir, err = os.MkdirTemp("", "ddtest")
+		if err != nil {
+			return err
+		}
+		a.tempDir = dir
+	}
+
+	var err error
+	if a.cfg.apmHTTP {
+		a.apmListener, err = net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			return fmt.Errorf("failed to listen for traces: %w", err)
+		}
+		a.apmEndpoint = "http://" + a.apmListener.Addr().String()
+	} else {
+		path := filepath.Join(dir, "apm.socket")
+		a.apmListener, err = net.Listen("unix", path)
+		if err != nil {
+			return fmt.Errorf("failed to listen for traces: %w", err)
+		}
+		a.apmEndpoint = "unix://" + path
+	}
+
+	if a.cfg.dsdUDP {
+		a.dsdConn, err = net.ListenPacket("udp", "127.0.0.1:0")
+		if err != nil {
+			return fmt.Errorf("failed to listen for DogStatsD: %w", err)
+		}
+		// The DogStatsD client treats an address without scheme as UDP.
+		a.dsdEndpoint = a.dsdConn.LocalAddr().String()
+	} else {
+		path := filepath.Join(dir, "dsd.socket")
+		a.dsdConn, err = net.ListenPacket("unixgram", path)
+		if err != nil {
+			return fmt.Errorf("failed to listen for DogStatsD: %w", err)
+		}
+		a.dsdEndpoint = "unix://" + path
+	}
+
+	a.apmServer = &http.Server{
+		Handler:           a.apmHandler(),
+		ReadHeaderTimeout: 5 * time.Second,
+	}
+	a.wg.Go(func() {
+		err := a.apmServer.Serve(a.apmListener)
+		if err != nil && !errors.Is(err, http.ErrServerClosed) {
+			a.recordError(fmt.Errorf("trace server stopped: %w", err))
+		}
+	})
+	a.wg.Go(a.readDogStatsD)
+
+	return nil
+}
+
+func (a *Agent) stop() {
+	if a.apmServer != nil {
+		_ = a.apmServer.Close() //nolint:errcheck
+	} else if a.apmListener != nil {
+		_ = a.apmListener.Close() //nolint:errcheck
+	}
+	if a.dsdConn != nil {
+		_ = a.dsdConn.Close() //nolint:errcheck
+	}
+	a.wg.Wait()
+	if a.tempDir != "" {
+		_ = os.RemoveAll(a.tempDir) //nolint:errcheck
+	}
+}
+
+// APMEndpoint returns the URL traces are received on, as set in
+// DD_TRACE_AGENT_URL.
+func (a *Agent) APMEndpoint() string {
+	return a.apmEndpoint
+}
+
+// DSDEndpoint returns the address DogStatsD datagrams are received on, as set
+// in DD_DOGSTATSD_URL.
+func (a *Agent) DSDEndpoint() string {
+	return a.dsdEndpoint
+}
+
+// Reset discards everything received so far.
+func (a *Agent) Reset() {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.spans = nil
+	a.metrics = nil
+	a.events = nil
+	a.serviceChecks = nil
+}
+
+// recordError stores an error that will fail the test when the agent is
+// stopped. Errors cannot be reported directly, since they happen outside the
+// test's goroutine.
+func (a *Agent) recordError(err error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.errs = append(a.errs, err)
+}
+
+// waitFor polls cond until it returns true or the wait timeout is reached.
+func (a *Agent) waitFor(cond func() bool) bool {
+	deadline := time.Now().Add(a.cfg.waitTimeout)
+	for {
+		if cond() {
+			return true
+		}
+		if time.Now().After(deadline) {
+			return false
+		}
+		time.Sleep(pollInterval)
+	}
+}
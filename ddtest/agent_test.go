@@ -0,0 +1,142 @@
+package ddtest_test
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/DataDog/datadog-go/v5/statsd"
+	"github.com/DataDog/dd-trace-go/v2/ddtrace/tracer"
+	coopdatadog "github.com/coopnorge/go-datadog-lib/v2"
+	"github.com/coopnorge/go-datadog-lib/v2/ddtest"
+	"github.com/coopnorge/go-datadog-lib/v2/internal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAgentReceivesTraces(t *testing.T) {
+	tests := []struct {
+		name    string
+		options []ddtest.Option
+	}{
+		{"Unix socket", nil},
+		{"HTTP", []ddtest.Option{ddtest.WithHTTP()}},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			agent := ddtest.StartAgent(t, tc.options...)
+
+			err := tracer.Start()
+			require.NoError(t, err)
+
+			parent := tracer.StartSpan("parent.operation", tracer.ResourceName("/parent"))
+			child := parent.StartChild("child.operation", tracer.Tag("some_key", "some_value"))
+			child.Finish()
+			parent.Finish()
+			tracer.Stop()
+
+			spans := agent.RequireSpans(t, 2)
+			assert.Len(t, spans, 2)
+
+			parentSpan := agent.RequireSpan(t, "parent.operation")
+			assert.Equal(t, "/parent", parentSpan.Resource)
+			assert.Equal(t, "unittest-service", parentSpan.Service)
+			assert.Equal(t, "unittest", parentSpan.Tag("env"))
+			assert.False(t, parentSpan.IsError())
+
+			childSpan := agent.RequireSpan(t, "child.operation")
+			assert.Equal(t, "some_value", childSpan.Tag("some_key"))
+			assert.Equal(t, parentSpan.SpanID, childSpan.ParentID)
+			assert.Equal(t, parentSpan.TraceID, childSpan.TraceID)
+		})
+	}
+}
+
+func TestAgentReceivesDogStatsD(t *testing.T) {
+	tests := []struct {
+		name    string
+		options []ddtest.Option
+	}{
+		{"Unix socket", nil},
+		{"UDP", []ddtest.Option{ddtest.WithUDP()}},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			agent := ddtest.StartAgent(t, tc.options...)
+
+			client, err := statsd.New(agent.DSDEndpoint(), statsd.WithoutTelemetry())
+			require.NoError(t, err)
+
+			require.NoError(t, client.Gauge("my.gauge", 42, []string{"tag1:value1"}, 1))
+			require.NoError(t, client.Distribution("my.distribution", 1.5, nil, 1))
+			require.NoError(t, client.SimpleEvent("my title", "my text"))
+			require.NoError(t, client.SimpleServiceCheck("my.check", statsd.Warn))
+			require.NoError(t, client.Close())
+
+			gauge := agent.RequireMetric(t, "my.gauge")
+			assert.Equal(t, ddtest.MetricTypeGauge, gauge.Type)
+			assert.Equal(t, 42.0, gauge.Value)
+			assert.True(t, gauge.HasTag("tag1:value1"))
+
+			distribution := agent.RequireMetric(t, "my.distribution")
+			assert.Equal(t, ddtest.MetricTypeDistribution, distribution.Type)
+			assert.Equal(t, 1.5, distribution.Value)
+
+			event := agent.RequireEvent(t, "my title")
+			assert.Equal(t, "my text", event.Text)
+
+			serviceCheck := agent.RequireServiceCheck(t, "my.check")
+			assert.Equal(t, int(statsd.Warn), serviceCheck.Status)
+		})
+	}
+}
+
+func TestAgentReceivesFromStart(t *testing.T) {
+	agent := ddtest.StartAgent(t)
+
+	stop, err := coopdatadog.Start(context.Background())
+	require.NoError(t, err)
+
+	span := tracer.StartSpan("my.operation")
+	span.Finish()
+
+	// Stopping flushes traces and metrics to the agent.
+	require.NoError(t, stop())
+
+	got := agent.RequireSpan(t, "my.operation")
+	assert.Equal(t, "unittest-service", got.Service)
+	assert.Equal(t, "unittest", got.Tag("env"))
+}
+
+func TestAgentSetsEnvironment(t *testing.T) {
+	t.Setenv(internal.DatadogService, "my-service")
+
+	agent := ddtest.StartAgent(t)
+
+	assert.Equal(t, "false", getenv(t, internal.DatadogDisable))
+	assert.Equal(t, agent.APMEndpoint(), getenv(t, internal.DatadogAPMEndpoint))
+	assert.Equal(t, agent.DSDEndpoint(), getenv(t, internal.DatadogDSDEndpoint))
+	assert.Equal(t, "unittest", getenv(t, internal.DatadogEnvironment))
+	assert.Equal(t, "my-service", getenv(t, internal.DatadogService), "an already set environment variable was overridden")
+}
+
+func TestAgentReset(t *testing.T) {
+	agent := ddtest.StartAgent(t)
+
+	client, err := statsd.New(agent.DSDEndpoint(), statsd.WithoutTelemetry())
+	require.NoError(t, err)
+	require.NoError(t, client.Incr("my.counter", nil, 1))
+	require.NoError(t, client.Flush())
+	agent.RequireMetric(t, "my.counter")
+
+	agent.Reset()
+	assert.Empty(t, agent.Metrics())
+	require.NoError(t, client.Close())
+}
+
+func getenv(t *testing.T, key string) string {
+	t.Helper()
+	val, ok := os.LookupEnv(key)
+	require.True(t, ok, "environment variable %q not set", key)
+	return val
+}
@@ -0,0 +1,375 @@
+package ddtest
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// maxDatagramSize is the largest datagram the DogStatsD client will send over
+// a Unix socket.
+const maxDatagramSize = 64 * 1024
+
+// MetricType is the DogStatsD type of a metric.
+type MetricType string
+
+// Metric types as they appear in DogStatsD datagrams.
+const (
+	MetricTypeGauge        MetricType = "g"
+	MetricTypeCount        MetricType = "c"
+	MetricTypeHistogram    MetricType = "h"
+	MetricTypeDistribution MetricType = "d"
+	MetricTypeSet          MetricType = "s"
+	MetricTypeTiming       MetricType = "ms"
+)
+
+// Metric is a single metric sample as received by the agent.
+type Metric struct {
+	Name string
+	// Value is the numeric value of the sample. For sets Value is zero, use
+	// RawValue instead.
+	Value float64
+	// RawValue is the value as it appeared in the datagram.
+	RawValue   string
+	Type       MetricType
+	Tags       []string
+	SampleRate float64
+}
+
+// HasTag reports whether the metric has the tag, formatted as "key:value".
+func (m Metric) HasTag(tag string) bool {
+	return slices.Contains(m.Tags, tag)
+}
+
+// Event is an event as received by the agent.
+type Event struct {
+	Title          string
+	Text           string
+	Timestamp      int64
+	Hostname       string
+	AggregationKey string
+	Priority       string
+	SourceTypeName string
+	AlertType      string
+	Tags           []string
+}
+
+// ServiceCheck is a service check as received by the agent.
+type ServiceCheck struct {
+	Name      string
+	Status    int
+	Timestamp int64
+	Hostname  string
+	Message   string
+	Tags      []string
+}
+
+func (a *Agent) readDogStatsD() {
+	buf := make([]byte, maxDatagramSize)
+	for {
+		n, _, err := a.dsdConn.ReadFrom(buf)
+		if err != nil {
+			if !errors.Is(err, net.ErrClosed) {
+				a.recordError(fmt.Errorf("failed to read DogStatsD datagram: %w", err))
+			}
+			return
+		}
+		a.handleDatagram(buf[:n])
+	}
+}
+
+func (a *Agent) handleDatagram(datagram []byte) {
+	for line := range bytes.SplitSeq(datagram, []byte("\n")) {
+		if len(line) == 0 {
+			continue
+		}
+		err := a.handleLine(string(line))
+		if err != nil {
+			a.recordError(err)
+		}
+	}
+}
+
+func (a *Agent) handleLine(line string) error {
+	switch {
+	case strings.HasPrefix(line, "_e{"):
+		e, err := parseEvent(line)
+		if err != nil {
+			return err
+		}
+		a.mu.Lock()
+		a.events = append(a.events, e)
+		a.mu.Unlock()
+	case strings.HasPrefix(line, "_sc|"):
+		sc, err := parseServiceCheck(line)
+		if err != nil {
+			return err
+		}
+		a.mu.Lock()
+		a.serviceChecks = append(a.serviceChecks, sc)
+		a.mu.Unlock()
+	default:
+		ms, err := parseMetric(line)
+		if err != nil {
+			return err
+		}
+		a.mu.Lock()
+		a.metrics = append(a.metrics, ms...)
+		a.mu.Unlock()
+	}
+	return nil
+}
+
+// parseMetric parses a line on the format
+// "name:value[:value...]|type[|@rate][|#tag,tag][|...]". Lines with multiple
+// values, as sent by client-side aggregation, result in one Metric per value.
+func parseMetric(line string) ([]Metric, error) {
+	nameAndValues, rest, ok := strings.Cut(line, "|")
+	if !ok {
+		return nil, fmt.Errorf("malformed metric %q: missing type", line)
+	}
+	name, rawValues, ok := strings.Cut(nameAndValues, ":")
+	if !ok || name == "" {
+		return nil, fmt.Errorf("malformed metric %q: missing value", line)
+	}
+	fields := strings.Split(rest, "|")
+	metricType := MetricType(fields[0])
+	sampleRate := 1.0
+	var tags []string
+	for _, field := range fields[1:] {
+		switch {
+		case strings.HasPrefix(field, "@"):
+			rate, err := strconv.ParseFloat(field[1:], 64)
+			if err != nil {
+				return nil, fmt.Errorf("malformed metric %q: invalid sample rate: %w", line, err)
+			}
+			sampleRate = rate
+		case strings.HasPrefix(field, "#"):
+			tags = parseTags(field[1:])
+		}
+	}
+
+	// Set values are arbitrary strings, and are never packed.
+	values := []string{rawValues}
+	if metricType != MetricTypeSet {
+		values = strings.Split(rawValues, ":")
+	}
+	ms := make([]Metric, 0, len(values))
+	for _, raw := range values {
+		m := Metric{
+			Name:       name,
+			RawValue:   raw,
+			Type:       metricType,
+			Tags:       tags,
+			SampleRate: sampleRate,
+		}
+		if metricType != MetricTypeSet {
+			value, err := strconv.ParseFloat(raw, 64)
+			if err != nil {
+				return nil, fmt.Errorf("malformed metric %q: invalid value: %w", line, err)
+			}
+			m.Value = value
+		}
+		ms = append(ms, m)
+	}
+	return ms, nil
+}
+
+// parseEvent parses a line on the format
+// "_e{title.length,text.length}:title|text[|field...]".
+func parseEvent(line string) (Event, error) {
+	header, body, ok := strings.Cut(line[len("_e{"):], "}:")
+	if !ok {
+		return Event{}, fmt.Errorf("malformed event %q", line)
+	}
+	titleLenStr, textLenStr, ok := strings.Cut(header, ",")
+	if !ok {
+		return Event{}, fmt.Errorf("malformed event %q", line)
+	}
+	titleLen, err := strconv.Atoi(titleLenStr)
+	if err != nil {
+		return Event{}, fmt.Errorf("malformed event %q: %w", line, err)
+	}
+	textLen, err := strconv.Atoi(textLenStr)
+	if err != nil {
+		return Event{}, fmt.Errorf("malformed event %q: %w", line, err)
+	}
+	if len(body) < titleLen+1+textLen {
+		return Event{}, fmt.Errorf("malformed event %q: too short", line)
+	}
+
+	e := Event{
+		Title: body[:titleLen],
+		Text:  strings.ReplaceAll(body[titleLen+1:titleLen+1+textLen], `\n`, "\n"),
+	}
+	rest := strings.TrimPrefix(body[titleLen+1+textLen:], "|")
+	if rest == "" {
+		return e, nil
+	}
+	for field := range strings.SplitSeq(rest, "|") {
+		switch {
+		case strings.HasPrefix(field, "d:"):
+			e.Timestamp, err = strconv.ParseInt(field[2:], 10, 64)
+			if err != nil {
+				return Event{}, fmt.Errorf("malformed event %q: %w", line, err)
+			}
+		case strings.HasPrefix(field, "h:"):
+			e.Hostname = field[2:]
+		case strings.HasPrefix(field, "k:"):
+			e.AggregationKey = field[2:]
+		case strings.HasPrefix(field, "p:"):
+			e.Priority = field[2:]
+		case strings.HasPrefix(field, "s:"):
+			e.SourceTypeName = field[2:]
+		case strings.HasPrefix(field, "t:"):
+			e.AlertType = field[2:]
+		case strings.HasPrefix(field, "#"):
+			e.Tags = parseTags(field[1:])
+		}
+	}
+	return e, nil
+}
+
+// parseServiceCheck parses a line on the format
+// "_sc|name|status[|field...]".
+func parseServiceCheck(line string) (ServiceCheck, error) {
+	fields := strings.Split(line, "|")
+	if len(fields) < 3 {
+		return ServiceCheck{}, fmt.Errorf("malformed service check %q", line)
+	}
+	status, err := strconv.Atoi(fields[2])
+	if err != nil {
+		return ServiceCheck{}, fmt.Errorf("malformed service check %q: %w", line, err)
+	}
+	sc := ServiceCheck{
+		Name:   fields[1],
+		Status: status,
+	}
+	for _, field := range fields[3:] {
+		switch {
+		case strings.HasPrefix(field, "d:"):
+			sc.Timestamp, err = strconv.ParseInt(field[2:], 10, 64)
+			if err != nil {
+				return ServiceCheck{}, fmt.Errorf("malformed service check %q: %w", line, err)
+			}
+		case strings.HasPrefix(field, "h:"):
+			sc.Hostname = field[2:]
+		case strings.HasPrefix(field, "m:"):
+			sc.Message = strings.ReplaceAll(field[2:], `\n`, "\n")
+		case strings.HasPrefix(field, "#"):
+			sc.Tags = parseTags(field[1:])
+		}
+	}
+	return sc, nil
+}
+
+func parseTags(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Split(s, ",")
+}
+
+// Metrics returns every metric sample received so far.
+func (a *Agent) Metrics() []Metric {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	metrics := make([]Metric, len(a.metrics))
+	copy(metrics, a.metrics)
+	return metrics
+}
+
+// MetricsByName returns every metric sample received so far with the name.
+func (a *Agent) MetricsByName(name string) []Metric {
+	var found []Metric
+	for _, m := range a.Metrics() {
+		if m.Name == name {
+			found = append(found, m)
+		}
+	}
+	return found
+}
+
+// Events returns every event received so far.
+func (a *Agent) Events() []Event {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	events := make([]Event, len(a.events))
+	copy(events, a.events)
+	return events
+}
+
+// ServiceChecks returns every service check received so far.
+func (a *Agent) ServiceChecks() []ServiceCheck {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	serviceChecks := make([]ServiceCheck, len(a.serviceChecks))
+	copy(serviceChecks, a.serviceChecks)
+	return serviceChecks
+}
+
+// RequireMetric waits for a metric sample with the name to be received, and
+// returns the first one found. The test is stopped if no such metric is
+// received before the wait timeout.
+//
+// The DogStatsD client buffers metrics, so call metrics.Flush before calling
+// RequireMetric.
+func (a *Agent) RequireMetric(t testing.TB, name string) Metric {
+	t.Helper()
+	var found []Metric
+	ok := a.waitFor(func() bool {
+		found = a.MetricsByName(name)
+		return len(found) > 0
+	})
+	if !ok {
+		t.Fatalf("no metric with name %q received", name)
+	}
+	return found[0]
+}
+
+// RequireEvent waits for an event with the title to be received, and returns
+// it. The test is stopped if no such event is received before the wait
+// timeout.
+func (a *Agent) RequireEvent(t testing.TB, title string) Event {
+	t.Helper()
+	var found Event
+	ok := a.waitFor(func() bool {
+		for _, e := range a.Events() {
+			if e.Title == title {
+				found = e
+				return true
+			}
+		}
+		return false
+	})
+	if !ok {
+		t.Fatalf("no event with title %q received", title)
+	}
+	return found
+}
+
+// RequireServiceCheck waits for a service check with the name to be received,
+// and returns it. The test is stopped if no such service check is received
+// before the wait timeout.
+func (a *Agent) RequireServiceCheck(t testing.TB, name string) ServiceCheck {
+	t.Helper()
+	var found ServiceCheck
+	ok := a.waitFor(func() bool {
+		for _, sc := range a.ServiceChecks() {
+			if sc.Name == name {
+				found = sc
+				return true
+			}
+		}
+		return false
+	})
+	if !ok {
+		t.Fatalf("no service check with name %q received", name)
+	}
+	return found
+}
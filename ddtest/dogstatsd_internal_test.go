@@ -0,0 +1,108 @@
+package ddtest
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseMetric(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		line    string
+		want    []Metric
+		wantErr bool
+	}{
+		{
+			name: "Gauge",
+			line: "my.gauge:42|g",
+			want: []Metric{{Name: "my.gauge", Value: 42, RawValue: "42", Type: MetricTypeGauge, SampleRate: 1}},
+		},
+		{
+			name: "Count with sample rate and tags",
+			line: "my.count:-1|c|@0.5|#tag1:value1,tag2:value2",
+			want: []Metric{{Name: "my.count", Value: -1, RawValue: "-1", Type: MetricTypeCount, SampleRate: 0.5, Tags: []string{"tag1:value1", "tag2:value2"}}},
+		},
+		{
+			name: "Packed distribution",
+			line: "my.dist:1:2.5|d|#tag1:value1",
+			want: []Metric{
+				{Name: "my.dist", Value: 1, RawValue: "1", Type: MetricTypeDistribution, SampleRate: 1, Tags: []string{"tag1:value1"}},
+				{Name: "my.dist", Value: 2.5, RawValue: "2.5", Type: MetricTypeDistribution, SampleRate: 1, Tags: []string{"tag1:value1"}},
+			},
+		},
+		{
+			name: "Set with colon in value",
+			line: "my.set:user:1|s",
+			want: []Metric{{Name: "my.set", RawValue: "user:1", Type: MetricTypeSet, SampleRate: 1}},
+		},
+		{
+			name: "Unknown fields are ignored",
+			line: "my.timing:12|ms|c:container-id|T1700000000",
+			want: []Metric{{Name: "my.timing", Value: 12, RawValue: "12", Type: MetricTypeTiming, SampleRate: 1}},
+		},
+		{"Missing type", "my.gauge:42", nil, true},
+		{"Missing value", "my.gauge|g", nil, true},
+		{"Invalid value", "my.gauge:abc|g", nil, true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := parseMetric(tc.line)
+			if tc.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.want, got)
+		})
+	}
+}
+
+func TestParseEvent(t *testing.T) {
+	t.Parallel()
+
+	got, err := parseEvent(`_e{5,11}:title|line\nbreak|d:1700000000|h:my-host|k:my-key|p:low|s:my-source|t:warning|#tag1:value1`)
+	require.NoError(t, err)
+	assert.Equal(t, Event{
+		Title:          "title",
+		Text:           "line\nbreak",
+		Timestamp:      1700000000,
+		Hostname:       "my-host",
+		AggregationKey: "my-key",
+		Priority:       "low",
+		SourceTypeName: "my-source",
+		AlertType:      "warning",
+		Tags:           []string{"tag1:value1"},
+	}, got)
+
+	got, err = parseEvent("_e{5,4}:title|text")
+	require.NoError(t, err)
+	assert.Equal(t, Event{Title: "title", Text: "text"}, got)
+
+	_, err = parseEvent("_e{50,4}:title|text")
+	require.Error(t, err)
+}
+
+func TestParseServiceCheck(t *testing.T) {
+	t.Parallel()
+
+	got, err := parseServiceCheck("_sc|my.check|2|d:1700000000|h:my-host|#tag1:value1|m:something failed")
+	require.NoError(t, err)
+	assert.Equal(t, ServiceCheck{
+		Name:      "my.check",
+		Status:    2,
+		Timestamp: 1700000000,
+		Hostname:  "my-host",
+		Message:   "something failed",
+		Tags:      []string{"tag1:value1"},
+	}, got)
+
+	_, err = parseServiceCheck("_sc|my.check")
+	require.Error(t, err)
+}
@@ -0,0 +1,61 @@
+package ddtest
+
+import "time"
+
+type config struct {
+	apmHTTP     bool
+	dsdUDP      bool
+	env         string
+	service     string
+	version     string
+	waitTimeout time.Duration
+}
+
+func defaults() *config {
+	return &config{
+		apmHTTP:     false,
+		dsdUDP:      false,
+		env:         "unittest",
+		service:     "unittest-service",
+		version:     "v0.0.0",
+		waitTimeout: defaultWaitTimeout,
+	}
+}
+
+// Option allows for overriding our default-config.
+type Option func(cfg *config)
+
+// WithHTTP makes the agent receive traces over HTTP on a loopback TCP port
+// instead of a Unix socket.
+func WithHTTP() Option {
+	return func(cfg *config) {
+		cfg.apmHTTP = true
+	}
+}
+
+// WithUDP makes the agent receive DogStatsD datagrams on a loopback UDP port
+// instead of a Unix socket.
+func WithUDP() Option {
+	return func(cfg *config) {
+		cfg.dsdUDP = true
+	}
+}
+
+// WithUnifiedServiceTags sets the values used for DD_ENV, DD_SERVICE and
+// DD_VERSION. The environment variables are only set if they are not already
+// set. Defaults to "unittest", "unittest-service" and "v0.0.0".
+func WithUnifiedServiceTags(env, service, version string) Option {
+	return func(cfg *config) {
+		cfg.env = env
+		cfg.service = service
+		cfg.version = version
+	}
+}
+
+// WithWaitTimeout sets how long the Require-functions wait for expected
+// traces and metrics to arrive, defaults to 5 seconds.
+func WithWaitTimeout(timeout time.Duration) Option {
+	return func(cfg *config) {
+		cfg.waitTimeout = timeout
+	}
+}
@@ -0,0 +1,146 @@
+package ddtest
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/tinylib/msgp/msgp"
+)
+
+// Span is a span as received by the agent.
+type Span struct {
+	Name     string             `json:"name"`
+	Service  string             `json:"service"`
+	Resource string             `json:"resource"`
+	Type     string             `json:"type"`
+	Start    int64              `json:"start"`
+	Duration int64              `json:"duration"`
+	Meta     map[string]string  `json:"meta"`
+	Metrics  map[string]float64 `json:"metrics"`
+	SpanID   uint64             `json:"span_id"`
+	TraceID  uint64             `json:"trace_id"`
+	ParentID uint64             `json:"parent_id"`
+	Error    int32              `json:"error"`
+}
+
+// Tag returns the string tag set on the span by key, or an empty string if it
+// is not set.
+func (s Span) Tag(key string) string {
+	return s.Meta[key]
+}
+
+// IsError reports whether the span is marked as an error.
+func (s Span) IsError() bool {
+	return s.Error != 0
+}
+
+func (a *Agent) apmHandler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v0.4/traces", a.handleTraces)
+	// Returning 404 from the info-endpoint makes the tracer fall back to the
+	// v0.4 protocol, which is the only protocol the fake agent decodes.
+	mux.HandleFunc("/info", http.NotFound)
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		// Profiles, stats and anything else is accepted and discarded.
+		_, _ = io.Copy(io.Discard, r.Body) //nolint:errcheck
+		w.WriteHeader(http.StatusOK)
+	})
+	return mux
+}
+
+func (a *Agent) handleTraces(w http.ResponseWriter, r *http.Request) {
+	traces, err := decodeTraces(r.Body)
+	if err != nil {
+		a.recordError(fmt.Errorf("failed to decode traces: %w", err))
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	a.mu.Lock()
+	for _, trace := range traces {
+		a.spans = append(a.spans, trace...)
+	}
+	a.mu.Unlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write([]byte("{}")) //nolint:errcheck
+}
+
+// decodeTraces decodes a msgpack-encoded v0.4 trace payload.
+func decodeTraces(r io.Reader) ([][]Span, error) {
+	buf := &bytes.Buffer{}
+	_, err := msgp.CopyToJSON(buf, r)
+	if err != nil {
+		return nil, err
+	}
+	var traces [][]Span
+	err = json.Unmarshal(buf.Bytes(), &traces)
+	if err != nil {
+		return nil, err
+	}
+	return traces, nil
+}
+
+// Spans returns every span received so far.
+func (a *Agent) Spans() []Span {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	spans := make([]Span, len(a.spans))
+	copy(spans, a.spans)
+	return spans
+}
+
+// SpansByName returns every span received so far with the operation name.
+func (a *Agent) SpansByName(name string) []Span {
+	return a.FindSpans(func(s Span) bool { return s.Name == name })
+}
+
+// FindSpans returns every span received so far that match returns true for.
+func (a *Agent) FindSpans(match func(Span) bool) []Span {
+	var found []Span
+	for _, s := range a.Spans() {
+		if match(s) {
+			found = append(found, s)
+		}
+	}
+	return found
+}
+
+// RequireSpan waits for a span with the operation name to be received, and
+// returns the first one found. The test is stopped if no such span is
+// received before the wait timeout.
+//
+// Traces are sent when the tracer flushes, so either stop the tracer or call
+// tracer.Flush before calling RequireSpan.
+func (a *Agent) RequireSpan(t testing.TB, name string) Span {
+	t.Helper()
+	var found []Span
+	ok := a.waitFor(func() bool {
+		found = a.SpansByName(name)
+		return len(found) > 0
+	})
+	if !ok {
+		t.Fatalf("no span with name %q received, got %d other spans", name, len(a.Spans()))
+	}
+	return found[0]
+}
+
+// RequireSpans waits until at least n spans have been received, and returns
+// all received spans. The test is stopped if fewer spans are received before
+// the wait timeout.
+func (a *Agent) RequireSpans(t testing.TB, n int) []Span {
+	t.Helper()
+	var spans []Span
+	ok := a.waitFor(func() bool {
+		spans = a.Spans()
+		return len(spans) >= n
+	})
+	if !ok {
+		t.Fatalf("expected at least %d spans, got %d", n, len(spans))
+	}
+	return spans
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"os"
 	"strconv"
+	"time"
 )
 
 const (
@@ -21,6 +22,24 @@ const (
 	DatadogDSDEndpoint = "DD_DOGSTATSD_URL"
 	// DatadogAPMEndpoint is the environment variable key for the URL to APM.
 	DatadogAPMEndpoint = "DD_TRACE_AGENT_URL"
+	// DatadogDSDNamespace is the environment variable key for the namespace prefixed to every metric name.
+	DatadogDSDNamespace = "DD_DOGSTATSD_NAMESPACE"
+	// DatadogDSDClientSideAggregation is the environment variable key for whether to aggregate metrics in the client.
+	DatadogDSDClientSideAggregation = "DD_DOGSTATSD_CLIENT_SIDE_AGGREGATION"
+	// DatadogDSDExtendedClientSideAggregation is the environment variable key for whether to also aggregate histograms, distributions and timings in the client.
+	DatadogDSDExtendedClientSideAggregation = "DD_DOGSTATSD_EXTENDED_CLIENT_SIDE_AGGREGATION"
+	// DatadogDSDAggregationInterval is the environment variable key for the interval that aggregated metrics are flushed on.
+	DatadogDSDAggregationInterval = "DD_DOGSTATSD_AGGREGATION_INTERVAL"
+	// DatadogDSDChannelMode is the environment variable key for whether to use channel mode in the client.
+	DatadogDSDChannelMode = "DD_DOGSTATSD_CHANNEL_MODE"
+	// DatadogDSDChannelModeBufferSize is the environment variable key for the size of the channel in channel mode.
+	DatadogDSDChannelModeBufferSize = "DD_DOGSTATSD_CHANNEL_MODE_BUFFER_SIZE"
+	// DatadogDSDBufferPoolSize is the environment variable key for the number of buffers used to serialize metrics.
+	DatadogDSDBufferPoolSize = "DD_DOGSTATSD_BUFFER_POOL_SIZE"
+	// DatadogDSDMaxBytesPerPayload is the environment variable key for the maximum size of a payload in bytes.
+	DatadogDSDMaxBytesPerPayload = "DD_DOGSTATSD_MAX_BYTES_PER_PAYLOAD"
+	// DatadogDSDMaxMessagesPerPayload is the environment variable key for the maximum number of metrics in a payload.
+	DatadogDSDMaxMessagesPerPayload = "DD_DOGSTATSD_MAX_MESSAGES_PER_PAYLOAD"
 )
 
 // IsDatadogDisabled checks if the Datadog integration is disabled. The
@@ -54,3 +73,36 @@ func GetBool(key string, fallback bool) bool {
 	}
 	return val
 }
+
+// ParseBool returns the boolean value of the environmental variable. If the
+// key is not set the fallback value is returned, and if parsing fails the
+// fallback value is returned with an error.
+func ParseBool(key string, fallback bool) (bool, error) {
+	return parseEnv(key, fallback, strconv.ParseBool)
+}
+
+// ParseInt returns the integer value of the environmental variable. If the key
+// is not set the fallback value is returned, and if parsing fails the fallback
+// value is returned with an error.
+func ParseInt(key string, fallback int) (int, error) {
+	return parseEnv(key, fallback, strconv.Atoi)
+}
+
+// ParseDuration returns the duration value of the environmental variable,
+// parsed with time.ParseDuration. If the key is not set the fallback value is
+// returned, and if parsing fails the fallback value is returned with an error.
+func ParseDuration(key string, fallback time.Duration) (time.Duration, error) {
+	return parseEnv(key, fallback, time.ParseDuration)
+}
+
+func parseEnv[T any](key string, fallback T, parse func(string) (T, error)) (T, error) {
+	valStr, ok := os.LookupEnv(key)
+	if !ok {
+		return fallback, nil
+	}
+	val, err := parse(valStr)
+	if err != nil {
+		return fallback, fmt.Errorf("invalid value of environmental variable %q: %w", key, err)
+	}
+	return val, nil
+}
@@ -4,6 +4,7 @@ import (
 	"os"
 	"strconv"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -42,3 +43,60 @@ func TestIsDatadogDisabledNonBoolValue(t *testing.T) {
 
 	assert.False(t, internal.IsDatadogDisabled())
 }
+
+func TestParseBool(t *testing.T) {
+	const key = "DD_TEST_BOOL"
+
+	t.Setenv(key, "true")
+	val, err := internal.ParseBool(key, false)
+	require.NoError(t, err)
+	assert.True(t, val)
+
+	t.Setenv(key, "Hello")
+	val, err = internal.ParseBool(key, false)
+	require.ErrorContains(t, err, key)
+	assert.False(t, val)
+
+	require.NoError(t, os.Unsetenv(key))
+	val, err = internal.ParseBool(key, true)
+	require.NoError(t, err)
+	assert.True(t, val)
+}
+
+func TestParseInt(t *testing.T) {
+	const key = "DD_TEST_INT"
+
+	t.Setenv(key, "42")
+	val, err := internal.ParseInt(key, 1)
+	require.NoError(t, err)
+	assert.Equal(t, 42, val)
+
+	t.Setenv(key, "Hello")
+	val, err = internal.ParseInt(key, 1)
+	require.ErrorContains(t, err, key)
+	assert.Equal(t, 1, val)
+
+	require.NoError(t, os.Unsetenv(key))
+	val, err = internal.ParseInt(key, 1)
+	require.NoError(t, err)
+	assert.Equal(t, 1, val)
+}
+
+func TestParseDuration(t *testing.T) {
+	const key = "DD_TEST_DURATION"
+
+	t.Setenv(key, "5s")
+	val, err := internal.ParseDuration(key, time.Second)
+	require.NoError(t, err)
+	assert.Equal(t, 5*time.Second, val)
+
+	t.Setenv(key, "5")
+	val, err = internal.ParseDuration(key, time.Second)
+	require.ErrorContains(t, err, key)
+	assert.Equal(t, time.Second, val)
+
+	require.NoError(t, os.Unsetenv(key))
+	val, err = internal.ParseDuration(key, time.Second)
+	require.NoError(t, err)
+	assert.Equal(t, time.Second, val)
+}
@@ -0,0 +1,14 @@
+package internal
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+)
+
+// HashTagValue returns the first 16 hexadecimal characters of the SHA-256 hash
+// of value. It is used for tag values that must be correlated across spans,
+// but must not be readable, such as a user-id.
+func HashTagValue(value string) string {
+	sum := sha256.Sum256([]byte(value))
+	return hex.EncodeToString(sum[:8])
+}
@@ -0,0 +1,17 @@
+package internal_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/coopnorge/go-datadog-lib/v2/internal"
+)
+
+func TestHashTagValue(t *testing.T) {
+	hashed := internal.HashTagValue("user-42")
+	assert.Len(t, hashed, 16)
+	assert.NotContains(t, hashed, "user-42")
+	assert.Equal(t, hashed, internal.HashTagValue("user-42"))
+	assert.NotEqual(t, hashed, internal.HashTagValue("user-43"))
+}
@@ -0,0 +1,48 @@
+// Package recovery records panics recovered by the server middlewares.
+package recovery
+
+import (
+	"context"
+	"fmt"
+	"runtime/debug"
+
+	"github.com/DataDog/dd-trace-go/v2/ddtrace/ext"
+	"github.com/DataDog/dd-trace-go/v2/ddtrace/tracer"
+	"github.com/coopnorge/go-datadog-lib/v2/metrics"
+	"github.com/coopnorge/go-logger"
+)
+
+// MetricPanics is the name of the metric counting recovered panics.
+const MetricPanics = "panics"
+
+// Record records the value r, recovered from a panic while handling a request
+// to the resource in the component, such as "grpc". The span in the context
+// is marked as an error with the stack trace of the panic, the panic is
+// counted, and it is logged with the trace of the context. Record must be
+// called from the deferred function that recovered the panic, so that the
+// stack trace includes where the panic happened. Record returns an error
+// describing the panic.
+func Record(ctx context.Context, component, resource string, r any) error {
+	var err error
+	if e, ok := r.(error); ok {
+		err = fmt.Errorf("panic: %w", e)
+	} else {
+		err = fmt.Errorf("panic: %v", r)
+	}
+	stack := err.Error() + "\n\n" + string(debug.Stack())
+
+	if span, ok := tracer.SpanFromContext(ctx); ok {
+		span.SetTag(ext.ErrorNoStackTrace, err)
+		span.SetTag(ext.ErrorType, "panic")
+		span.SetTag(ext.ErrorStack, stack)
+	}
+
+	opts := []metrics.Option{metrics.WithTag("component", component)}
+	if resource != "" {
+		opts = append(opts, metrics.WithTag("resource", resource))
+	}
+	metrics.IncrCtx(ctx, MetricPanics, opts...)
+
+	logger.WithContext(ctx).WithError(err).WithField("stack", stack).Error("Recovered from panic")
+	return err
+}
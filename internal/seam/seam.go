@@ -0,0 +1,17 @@
+// Package seam gives the metricstest and ddtest packages access to the
+// unexported state of the metrics package, without making it part of the
+// public API.
+package seam
+
+import "github.com/DataDog/datadog-go/v5/statsd"
+
+// SwapDefaultStatsdClient replaces the statsd.ClientInterface used by the
+// package-level functions in metrics, and returns a function that restores
+// the previous one. It is set by the metrics package when initialized.
+var SwapDefaultStatsdClient func(c statsd.ClientInterface) (restore func())
+
+// SetupDefaultClient replaces the client used by the package-level functions
+// in metrics with a new one, configured from the environment like
+// GlobalSetup, and returns a function that closes it and restores the
+// previous one. It is set by the metrics package when initialized.
+var SetupDefaultClient func() (restore func(), err error)
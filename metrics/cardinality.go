@@ -0,0 +1,157 @@
+package metrics
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+)
+
+const (
+	// cardinalityOverflowMetric counts metrics that had tag values replaced
+	// because their cardinality limit was reached.
+	cardinalityOverflowMetric = "coopdatadog.metrics.cardinality_overflow"
+	// cardinalityOverflowValue replaces the values of offending tags.
+	cardinalityOverflowValue = "other"
+)
+
+// cardinalityGuard tracks the distinct tag combinations sent per metric, and
+// collapses tag values once a metric's limit is reached.
+type cardinalityGuard struct {
+	limits       map[string]int
+	defaultLimit int
+
+	mu      sync.Mutex
+	metrics map[string]*metricCardinality
+}
+
+// metricCardinality is the tracked state of a single metric.
+type metricCardinality struct {
+	series map[string]struct{}
+	// overflowSeries is the number of series in series that have collapsed
+	// tags, not counting the ones where every tag is collapsed.
+	overflowSeries int
+	// values holds the distinct values seen per tag key, within the limit.
+	values map[string]map[string]struct{}
+	// reported is set once the overflow has been reported to the error
+	// handler, so that it is not called for every metric.
+	reported bool
+}
+
+// newCardinalityGuard returns a cardinalityGuard for the limits in opts, or
+// nil if no limits are configured.
+func newCardinalityGuard(opts *options) *cardinalityGuard {
+	if len(opts.cardinalityLimits) == 0 && opts.defaultCardinalityLimit == 0 {
+		return nil
+	}
+	return &cardinalityGuard{
+		limits:       opts.cardinalityLimits,
+		defaultLimit: opts.defaultCardinalityLimit,
+		metrics:      map[string]*metricCardinality{},
+	}
+}
+
+func (g *cardinalityGuard) limit(name string) int {
+	if limit, ok := g.limits[name]; ok {
+		return limit
+	}
+	return g.defaultLimit
+}
+
+// check records the tag combination for the metric name. If the combination
+// is new and the limit is reached, the returned tags have the values of the
+// tags with the most distinct values replaced with "other", and overflowed is
+// true. reportErr is non-nil the first time the metric overflows.
+func (g *cardinalityGuard) check(name string, tags []string) (result []string, overflowed bool, reportErr error) {
+	limit := g.limit(name)
+	if limit <= 0 {
+		return tags, false, nil
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	m, ok := g.metrics[name]
+	if !ok {
+		m = &metricCardinality{
+			series: map[string]struct{}{},
+			values: map[string]map[string]struct{}{},
+		}
+		g.metrics[name] = m
+	}
+
+	key := seriesKey(tags)
+	if _, ok := m.series[key]; ok {
+		return tags, false, nil
+	}
+	if len(m.series) < limit {
+		m.series[key] = struct{}{}
+		for _, tag := range tags {
+			k, v, _ := strings.Cut(tag, ":")
+			if m.values[k] == nil {
+				m.values[k] = map[string]struct{}{}
+			}
+			m.values[k][v] = struct{}{}
+		}
+		return tags, false, nil
+	}
+
+	// The tags with the most distinct values are collapsed, one at a time,
+	// until the combination is a known series. New collapsed combinations
+	// are tracked until there are as many as the limit, after which tags are
+	// collapsed until every value is "other", so that the number of series
+	// stays bounded when several tags have unbounded values.
+	result = slices.Clone(tags)
+	var collapsed []string
+	for {
+		idx := m.mostDistinctTag(result)
+		if idx < 0 {
+			m.series[seriesKey(result)] = struct{}{}
+			break
+		}
+		k, _, _ := strings.Cut(result[idx], ":")
+		result[idx] = k + ":" + cardinalityOverflowValue
+		collapsed = append(collapsed, k)
+
+		key := seriesKey(result)
+		if _, ok := m.series[key]; ok {
+			break
+		}
+		if m.overflowSeries < limit {
+			m.series[key] = struct{}{}
+			m.overflowSeries++
+			break
+		}
+	}
+
+	if !m.reported {
+		m.reported = true
+		reportErr = fmt.Errorf("metric %s exceeded its cardinality limit of %d series, values of the tags %q are replaced with %q", name, limit, collapsed, cardinalityOverflowValue)
+	}
+	return result, true, reportErr
+}
+
+// mostDistinctTag returns the index of the tag in tags, that has not already
+// been collapsed, whose key has the most distinct values. It returns -1 if
+// there is no such tag.
+func (m *metricCardinality) mostDistinctTag(tags []string) int {
+	idx, most := -1, -1
+	for i, tag := range tags {
+		k, v, _ := strings.Cut(tag, ":")
+		if v == cardinalityOverflowValue {
+			continue
+		}
+		if n := len(m.values[k]); n > most {
+			idx, most = i, n
+		}
+	}
+	return idx
+}
+
+// seriesKey returns a key that identifies the tag combination, regardless of
+// the order of the tags.
+func seriesKey(tags []string) string {
+	sorted := slices.Clone(tags)
+	slices.Sort(sorted)
+	return strings.Join(sorted, ",")
+}
@@ -0,0 +1,92 @@
+package metrics //nolint:revive
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCardinalityGuard(t *testing.T) {
+	opts := defaultOptions()
+	require.NoError(t, opts.applyOptions([]Option{WithCardinalityLimit("orders", 2)}))
+	guard := newCardinalityGuard(opts)
+	require.NotNil(t, guard)
+
+	tags, overflowed, err := guard.check("orders", []string{"status:ok", "order_id:1"})
+	assert.False(t, overflowed)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"status:ok", "order_id:1"}, tags)
+
+	_, overflowed, _ = guard.check("orders", []string{"status:ok", "order_id:2"})
+	assert.False(t, overflowed)
+
+	// Known combinations are sent as-is, regardless of the order of the tags.
+	_, overflowed, _ = guard.check("orders", []string{"order_id:1", "status:ok"})
+	assert.False(t, overflowed)
+
+	tags, overflowed, err = guard.check("orders", []string{"status:ok", "order_id:3"})
+	assert.True(t, overflowed)
+	assert.ErrorContains(t, err, "exceeded its cardinality limit of 2 series")
+	assert.Equal(t, []string{"status:ok", "order_id:other"}, tags, "the tag with the most distinct values should be collapsed")
+
+	tags, overflowed, err = guard.check("orders", []string{"status:ok", "order_id:4"})
+	assert.True(t, overflowed)
+	assert.NoError(t, err, "the overflow should only be reported once")
+	assert.Equal(t, []string{"status:ok", "order_id:other"}, tags)
+
+	// Metrics without a limit are not tracked.
+	_, overflowed, _ = guard.check("other.metric", []string{"order_id:5"})
+	assert.False(t, overflowed)
+	assert.NotContains(t, guard.metrics, "other.metric")
+}
+
+func TestCardinalityGuardCollapsesUntilKnownSeries(t *testing.T) {
+	opts := defaultOptions()
+	require.NoError(t, opts.applyOptions([]Option{WithDefaultCardinalityLimit(1)}))
+	guard := newCardinalityGuard(opts)
+
+	_, overflowed, _ := guard.check("orders", []string{"status:ok"})
+	assert.False(t, overflowed)
+
+	tags, overflowed, _ := guard.check("orders", []string{"status:failed"})
+	assert.True(t, overflowed)
+	assert.Equal(t, []string{"status:other"}, tags)
+}
+
+func TestNoCardinalityGuardByDefault(t *testing.T) {
+	assert.Nil(t, newCardinalityGuard(defaultOptions()))
+}
+
+func TestCardinalityLimitOptions(t *testing.T) {
+	opts := defaultOptions()
+	assert.Error(t, opts.applyOptions([]Option{WithCardinalityLimit("", 1)}))
+	assert.Error(t, opts.applyOptions([]Option{WithCardinalityLimit("orders", 0)}))
+	assert.Error(t, opts.applyOptions([]Option{WithDefaultCardinalityLimit(-1)}))
+}
+
+func TestCardinalityGuardSeveralUnboundedTags(t *testing.T) {
+	opts := defaultOptions()
+	require.NoError(t, opts.applyOptions([]Option{WithCardinalityLimit("orders", 2)}))
+	guard := newCardinalityGuard(opts)
+
+	sent := map[string]struct{}{}
+	for i := range 100 {
+		tags, _, _ := guard.check("orders", []string{
+			"order_id:" + strconv.Itoa(i),
+			"user_id:" + strconv.Itoa(i),
+		})
+		sent[seriesKey(tags)] = struct{}{}
+	}
+
+	// 2 series within the limit, 2 with one collapsed tag, and the one where
+	// every tag is collapsed.
+	assert.Len(t, sent, 5)
+	assert.Len(t, guard.metrics["orders"].series, 5)
+	assert.Contains(t, sent, "order_id:other,user_id:other")
+
+	tags, overflowed, _ := guard.check("orders", []string{"order_id:1000", "user_id:1000"})
+	assert.True(t, overflowed)
+	assert.Equal(t, []string{"order_id:other", "user_id:other"}, tags)
+}
@@ -0,0 +1,45 @@
+package metrics_test
+
+import (
+	"testing"
+
+	"github.com/coopnorge/go-datadog-lib/v2/ddtest"
+	"github.com/coopnorge/go-datadog-lib/v2/metrics"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCardinalityLimit(t *testing.T) {
+	agent := ddtest.StartAgent(t)
+
+	var errs []error
+	client, err := metrics.NewClient(
+		metrics.WithErrorHandler(func(err error) { errs = append(errs, err) }),
+		metrics.WithCardinalityLimit("orders.created", 2),
+	)
+	require.NoError(t, err)
+
+	for _, orderID := range []string{"1", "2", "3", "4"} {
+		client.Incr("orders.created", metrics.WithTag("status", "ok"), metrics.WithTag("order_id", orderID))
+	}
+	require.NoError(t, client.Close())
+
+	assert.Len(t, errs, 1)
+	agent.RequireMetric(t, "coopdatadog.metrics.cardinality_overflow")
+
+	var collapsed int
+	for _, m := range agent.MetricsByName("orders.created") {
+		if m.HasTag("order_id:other") {
+			collapsed++
+			assert.True(t, m.HasTag("status:ok"))
+		}
+	}
+	// Client-side aggregation may have combined the two collapsed counts.
+	assert.NotZero(t, collapsed)
+	var overflows float64
+	for _, m := range agent.MetricsByName("coopdatadog.metrics.cardinality_overflow") {
+		assert.True(t, m.HasTag("metric:orders.created"))
+		overflows += m.Value
+	}
+	assert.Equal(t, 2.0, overflows)
+}
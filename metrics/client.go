@@ -0,0 +1,274 @@
+package metrics
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/DataDog/datadog-go/v5/statsd"
+	"github.com/coopnorge/go-datadog-lib/v2/internal"
+)
+
+// Client sends metrics to Dogstatsd. Unlike the package-level functions, which
+// use a Client configured by GlobalSetup, a Client can be created per
+// component, and is not shared with the rest of the application.
+type Client struct {
+	statsd      statsd.ClientInterface
+	opts        *options
+	cardinality *cardinalityGuard
+}
+
+// NewClient creates a Client, configured from the same environment variables
+// and Options as GlobalSetup. If the Datadog integration is disabled, a Client
+// that does not send anything is returned. It is the caller's responsibility
+// to call Close when the Client is no longer needed.
+func NewClient(options ...Option) (*Client, error) {
+	if internal.IsDatadogDisabled() {
+		return newNoOpClient(), nil
+	}
+
+	opts, err := resolveOptions(options)
+	if err != nil {
+		return nil, err
+	}
+
+	statsdClient, err := statsd.New(opts.dsdEndpoint, opts.statsdOptions()...)
+	if err != nil {
+		return nil, err
+	}
+	return &Client{statsd: statsdClient, opts: opts, cardinality: newCardinalityGuard(opts)}, nil
+}
+
+// newNoOpClient returns a Client that does not cause panics, and does not
+// send anything.
+func newNoOpClient() *Client {
+	return &Client{
+		statsd: &statsd.NoOpClient{},
+		opts:   defaultOptions(),
+	}
+}
+
+// Flush forces a flush of all the queued dogstatsd payloads.
+func (c *Client) Flush() error {
+	err := c.statsd.Flush()
+	if err != nil {
+		return fmt.Errorf("failed to flush: %w", err)
+	}
+	return nil
+}
+
+// Close flushes and closes the Client. The Client must not be used after it
+// is closed.
+func (c *Client) Close() error {
+	err := c.statsd.Close()
+	if err != nil {
+		return fmt.Errorf("failed to close: %w", err)
+	}
+	return nil
+}
+
+// Gauge measures the value of a metric at a particular time.
+func (c *Client) Gauge(name string, value float64, options ...Option) {
+	c.GaugeCtx(context.Background(), name, value, options...)
+}
+
+// GaugeCtx is like Gauge, but also adds the tags carried by ctx. See ContextWithTags.
+func (c *Client) GaugeCtx(ctx context.Context, name string, value float64, options ...Option) {
+	localOpts, err := c.resolveLocalOpts(ctx, name, options)
+	if err != nil {
+		localOpts.errorHandler(fmt.Errorf("failed to apply metric options: %w", err))
+		return
+	}
+	err = c.statsd.Gauge(name, value, localOpts.tags, localOpts.sampleRate)
+	if err != nil {
+		localOpts.errorHandler(fmt.Errorf("failed to send Gauge: %w", err))
+	}
+}
+
+// Count tracks how many times something happened per second.
+func (c *Client) Count(name string, value int64, options ...Option) {
+	c.CountCtx(context.Background(), name, value, options...)
+}
+
+// CountCtx is like Count, but also adds the tags carried by ctx. See ContextWithTags.
+func (c *Client) CountCtx(ctx context.Context, name string, value int64, options ...Option) {
+	localOpts, err := c.resolveLocalOpts(ctx, name, options)
+	if err != nil {
+		localOpts.errorHandler(fmt.Errorf("failed to apply metric options: %w", err))
+		return
+	}
+	err = c.statsd.Count(name, value, localOpts.tags, localOpts.sampleRate)
+	if err != nil {
+		localOpts.errorHandler(fmt.Errorf("failed to to send Count: %w", err))
+	}
+}
+
+// Histogram tracks the statistical distribution of a set of values on each host.
+func (c *Client) Histogram(name string, value float64, options ...Option) {
+	c.HistogramCtx(context.Background(), name, value, options...)
+}
+
+// HistogramCtx is like Histogram, but also adds the tags carried by ctx. See ContextWithTags.
+func (c *Client) HistogramCtx(ctx context.Context, name string, value float64, options ...Option) {
+	localOpts, err := c.resolveLocalOpts(ctx, name, options)
+	if err != nil {
+		localOpts.errorHandler(fmt.Errorf("failed to apply metric options: %w", err))
+		return
+	}
+	err = c.statsd.Histogram(name, value, localOpts.tags, localOpts.sampleRate)
+	if err != nil {
+		localOpts.errorHandler(fmt.Errorf("failed to to send Histogram: %w", err))
+	}
+}
+
+// Distribution tracks the statistical distribution of a set of values across your infrastructure.
+func (c *Client) Distribution(name string, value float64, options ...Option) {
+	c.DistributionCtx(context.Background(), name, value, options...)
+}
+
+// DistributionCtx is like Distribution, but also adds the tags carried by ctx. See ContextWithTags.
+func (c *Client) DistributionCtx(ctx context.Context, name string, value float64, options ...Option) {
+	localOpts, err := c.resolveLocalOpts(ctx, name, options)
+	if err != nil {
+		localOpts.errorHandler(fmt.Errorf("failed to apply metric options: %w", err))
+		return
+	}
+	err = c.statsd.Distribution(name, value, localOpts.tags, localOpts.sampleRate)
+	if err != nil {
+		localOpts.errorHandler(fmt.Errorf("failed to to send Distribution: %w", err))
+	}
+}
+
+// Decr is just Count of -1
+func (c *Client) Decr(name string, options ...Option) {
+	c.Count(name, -1, options...)
+}
+
+// DecrCtx is just CountCtx of -1
+func (c *Client) DecrCtx(ctx context.Context, name string, options ...Option) {
+	c.CountCtx(ctx, name, -1, options...)
+}
+
+// Incr is just Count of 1
+func (c *Client) Incr(name string, options ...Option) {
+	c.Count(name, 1, options...)
+}
+
+// IncrCtx is just CountCtx of 1
+func (c *Client) IncrCtx(ctx context.Context, name string, options ...Option) {
+	c.CountCtx(ctx, name, 1, options...)
+}
+
+// Set counts the number of unique elements in a group.
+func (c *Client) Set(name string, value string, options ...Option) {
+	c.SetCtx(context.Background(), name, value, options...)
+}
+
+// SetCtx is like Set, but also adds the tags carried by ctx. See ContextWithTags.
+func (c *Client) SetCtx(ctx context.Context, name string, value string, options ...Option) {
+	localOpts, err := c.resolveLocalOpts(ctx, name, options)
+	if err != nil {
+		localOpts.errorHandler(fmt.Errorf("failed to apply metric options: %w", err))
+		return
+	}
+	err = c.statsd.Set(name, value, localOpts.tags, localOpts.sampleRate)
+	if err != nil {
+		localOpts.errorHandler(fmt.Errorf("failed to to send Set: %w", err))
+	}
+}
+
+// Timing sends timing information, it is an alias for TimeInMilliseconds
+func (c *Client) Timing(name string, value time.Duration, options ...Option) {
+	c.TimeInMilliseconds(name, value.Seconds()*1000, options...)
+}
+
+// TimingCtx sends timing information, it is an alias for TimeInMillisecondsCtx
+func (c *Client) TimingCtx(ctx context.Context, name string, value time.Duration, options ...Option) {
+	c.TimeInMillisecondsCtx(ctx, name, value.Seconds()*1000, options...)
+}
+
+// TimeInMilliseconds sends timing information in milliseconds.
+func (c *Client) TimeInMilliseconds(name string, value float64, options ...Option) {
+	c.TimeInMillisecondsCtx(context.Background(), name, value, options...)
+}
+
+// TimeInMillisecondsCtx is like TimeInMilliseconds, but also adds the tags carried by ctx. See ContextWithTags.
+func (c *Client) TimeInMillisecondsCtx(ctx context.Context, name string, value float64, options ...Option) {
+	localOpts, err := c.resolveLocalOpts(ctx, name, options)
+	if err != nil {
+		localOpts.errorHandler(fmt.Errorf("failed to apply metric options: %w", err))
+		return
+	}
+	err = c.statsd.TimeInMilliseconds(name, value, localOpts.tags, localOpts.sampleRate)
+	if err != nil {
+		localOpts.errorHandler(fmt.Errorf("failed to to send TimeInMilliseconds: %w", err))
+	}
+}
+
+// SimpleEvent sends an event with the provided title and text.
+func (c *Client) SimpleEvent(title, text string) {
+	err := c.statsd.SimpleEvent(title, text)
+	if err != nil {
+		c.opts.errorHandler(fmt.Errorf("failed to send Event: %w", err))
+	}
+}
+
+// resolveLocalOpts returns the options for a single metric, with the options
+// and the tags carried by ctx applied, and the cardinality limit of the metric
+// name enforced. The returned options are never nil, so that errors can be
+// passed to its errorHandler.
+func (c *Client) resolveLocalOpts(ctx context.Context, name string, options []Option) (*options, error) {
+	localOpts, err := c.resolveLocalOptsWithoutLimits(ctx, options)
+	if err != nil {
+		return localOpts, err
+	}
+	c.limitCardinality(name, localOpts)
+	return localOpts, nil
+}
+
+// resolveLocalOptsWithoutLimits is like resolveLocalOpts, but does not
+// enforce cardinality limits. It is used for events and service checks, which
+// are not billed per series.
+func (c *Client) resolveLocalOptsWithoutLimits(ctx context.Context, options []Option) (*options, error) {
+	localOpts := c.getLocalOpts()
+	err := localOpts.applyOptions(options)
+	if err != nil {
+		return localOpts, err
+	}
+	err = localOpts.applyContextTags(ctx)
+	if err != nil {
+		return localOpts, err
+	}
+	return localOpts, nil
+}
+
+// limitCardinality replaces the tags of localOpts if the cardinality limit of
+// the metric name is reached.
+func (c *Client) limitCardinality(name string, localOpts *options) {
+	if c.cardinality == nil {
+		return
+	}
+	tags, overflowed, reportErr := c.cardinality.check(name, localOpts.tags)
+	if !overflowed {
+		return
+	}
+	localOpts.tags = tags
+	if reportErr != nil {
+		localOpts.errorHandler(reportErr)
+	}
+	err := c.statsd.Incr(cardinalityOverflowMetric, []string{"metric:" + name}, 1)
+	if err != nil {
+		localOpts.errorHandler(fmt.Errorf("failed to to send Count: %w", err))
+	}
+}
+
+// getLocalOpts will return a copy of the client's options, with a few modifications. New "Option"'s can be applied without mutating the client's options.
+func (c *Client) getLocalOpts() *options {
+	return &options{
+		errorHandler: c.opts.errorHandler,
+		sampleRate:   c.opts.sampleRate,
+		timerKind:    c.opts.timerKind,
+		hostname:     c.opts.hostname,
+		tags:         nil, // Note: We are not copying the client's tags, since they have already been passed to the StatsD-client.
+	}
+}
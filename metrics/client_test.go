@@ -0,0 +1,73 @@
+package metrics_test
+
+import (
+	"testing"
+
+	"github.com/coopnorge/go-datadog-lib/v2/ddtest"
+	"github.com/coopnorge/go-datadog-lib/v2/internal"
+	"github.com/coopnorge/go-datadog-lib/v2/metrics"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClient(t *testing.T) {
+	agent := ddtest.StartAgent(t)
+
+	client, err := metrics.NewClient(metrics.WithErrorHandler(func(err error) { t.Error(err) }))
+	require.NoError(t, err)
+
+	client.Gauge("client.gauge", 42.0, metrics.WithTag("tag1", "value1"))
+	client.Incr("client.counter")
+	client.Distribution("client.distribution", 100)
+	client.SimpleEvent("client title", "client text")
+	require.NoError(t, client.Close())
+
+	gauge := agent.RequireMetric(t, "client.gauge")
+	assert.Equal(t, 42.0, gauge.Value)
+	assert.True(t, gauge.HasTag("tag1:value1"))
+	assert.True(t, gauge.HasTag("service:unittest-service"))
+
+	counter := agent.RequireMetric(t, "client.counter")
+	assert.Equal(t, 1.0, counter.Value)
+
+	agent.RequireMetric(t, "client.distribution")
+	agent.RequireEvent(t, "client title")
+}
+
+func TestClientDatadogDisabled(t *testing.T) {
+	t.Setenv(internal.DatadogDisable, "true")
+
+	client, err := metrics.NewClient()
+	require.NoError(t, err)
+	client.Incr("my.counter")
+	assert.NoError(t, client.Flush())
+	assert.NoError(t, client.Close())
+}
+
+func TestClientMissingEnvVar(t *testing.T) {
+	t.Setenv(internal.DatadogDisable, "false")
+	t.Setenv(internal.DatadogDSDEndpoint, "")
+
+	_, err := metrics.NewClient()
+	assert.ErrorContains(t, err, "required environmental variable not set: ")
+}
+
+func TestClientWithStatsdTuning(t *testing.T) {
+	agent := ddtest.StartAgent(t)
+
+	client, err := metrics.NewClient(
+		metrics.WithErrorHandler(func(err error) { t.Error(err) }),
+		metrics.WithNamespace("checkout"),
+		metrics.WithExtendedClientSideAggregation(true),
+		metrics.WithChannelMode(1024),
+		metrics.WithMaxMessagesPerPayload(1),
+	)
+	require.NoError(t, err)
+
+	client.Distribution("order.value", 100)
+	client.Incr("orders")
+	require.NoError(t, client.Close())
+
+	agent.RequireMetric(t, "checkout.order.value")
+	agent.RequireMetric(t, "checkout.orders")
+}
@@ -0,0 +1,76 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+)
+
+type contextTagsKey struct{}
+
+type contextTag struct {
+	key   string
+	value string
+}
+
+// ContextWithTags returns a copy of ctx that carries the tags, given as
+// alternating keys and values. The tags are added to every metric sent with
+// one of the context-aware functions, such as GaugeCtx and CountCtx, using
+// the returned context or a context derived from it.
+//
+// Tags set on a parent context are kept, unless the same key is set again.
+// Tags set with WithTag on the individual metric take precedence over tags
+// with the same key carried in the context.
+//
+// The tags are validated like WithTag when a metric is sent, and invalid tags
+// are reported to the ErrorHandler.
+func ContextWithTags(ctx context.Context, kv ...string) context.Context {
+	existing := tagsFromContext(ctx)
+	tags := make([]contextTag, len(existing), len(existing)+(len(kv)+1)/2)
+	copy(tags, existing)
+	for i := 0; i < len(kv); i += 2 {
+		tag := contextTag{key: kv[i]}
+		if i+1 < len(kv) {
+			tag.value = kv[i+1]
+		}
+		idx := slices.IndexFunc(tags, func(t contextTag) bool { return t.key == tag.key })
+		if idx >= 0 {
+			tags[idx] = tag
+		} else {
+			tags = append(tags, tag)
+		}
+	}
+	return context.WithValue(ctx, contextTagsKey{}, tags)
+}
+
+func tagsFromContext(ctx context.Context) []contextTag {
+	if ctx == nil {
+		return nil
+	}
+	tags, _ := ctx.Value(contextTagsKey{}).([]contextTag)
+	return tags
+}
+
+// applyContextTags adds the tags carried by ctx, except for keys that have
+// already been set.
+func (opts *options) applyContextTags(ctx context.Context) error {
+	var errs []error
+	for _, tag := range tagsFromContext(ctx) {
+		if hasTagKey(opts.tags, tag.key) {
+			continue
+		}
+		err := WithTag(tag.key, tag.value)(opts)
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func hasTagKey(tags []string, key string) bool {
+	return slices.ContainsFunc(tags, func(tag string) bool {
+		k, _, _ := strings.Cut(tag, ":")
+		return k == key
+	})
+}
@@ -0,0 +1,68 @@
+package metrics_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/coopnorge/go-datadog-lib/v2/metrics"
+	"github.com/coopnorge/go-datadog-lib/v2/metrics/metricstest"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestContextWithTags(t *testing.T) {
+	recorder := metricstest.Start(t)
+
+	ctx := metrics.ContextWithTags(context.Background(), "tenant", "coop", "channel", "web")
+	ctx = metrics.ContextWithTags(ctx, "channel", "app", "store", "1234")
+
+	metrics.GaugeCtx(ctx, "my.gauge", 1)
+	metrics.CountCtx(ctx, "my.count", 1, metrics.WithTag("store", "5678"))
+	metrics.IncrCtx(ctx, "my.incr")
+	metrics.DecrCtx(ctx, "my.decr")
+	metrics.HistogramCtx(ctx, "my.histogram", 1)
+	metrics.DistributionCtx(ctx, "my.distribution", 1)
+	metrics.SetCtx(ctx, "my.set", "value")
+	metrics.TimingCtx(ctx, "my.timing", time.Second)
+	metrics.TimeInMillisecondsCtx(ctx, "my.ms", 1)
+
+	for _, name := range []string{"my.gauge", "my.incr", "my.decr", "my.histogram", "my.distribution", "my.set", "my.timing", "my.ms"} {
+		m := recorder.RequireMetric(t, name)
+		assert.ElementsMatch(t, []string{"tenant:coop", "channel:app", "store:1234"}, m.Tags, name)
+	}
+
+	count := recorder.RequireMetric(t, "my.count")
+	assert.ElementsMatch(t, []string{"tenant:coop", "channel:app", "store:5678"}, count.Tags, "the per-metric tag did not take precedence")
+}
+
+func TestContextWithTagsDoesNotModifyParent(t *testing.T) {
+	recorder := metricstest.Start(t)
+
+	parent := metrics.ContextWithTags(context.Background(), "tenant", "coop")
+	_ = metrics.ContextWithTags(parent, "tenant", "other", "channel", "web")
+
+	metrics.IncrCtx(parent, "my.count")
+	assert.Equal(t, []string{"tenant:coop"}, recorder.RequireMetric(t, "my.count").Tags)
+}
+
+func TestContextWithInvalidTags(t *testing.T) {
+	recorder := metricstest.Start(t)
+
+	tests := []struct {
+		name string
+		kv   []string
+	}{
+		{"Odd number of arguments", []string{"tenant"}},
+		{"Reserved key", []string{"service", "foo"}},
+		{"Empty value", []string{"tenant", ""}},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var errs []error
+			ctx := metrics.ContextWithTags(context.Background(), tc.kv...)
+			metrics.IncrCtx(ctx, "my.count", metrics.WithErrorHandler(func(err error) { errs = append(errs, err) }))
+			assert.Len(t, errs, 1)
+			recorder.AssertNotEmitted(t, "my.count")
+		})
+	}
+}
@@ -0,0 +1,193 @@
+package metrics
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/DataDog/datadog-go/v5/statsd"
+)
+
+// EventAlertType is the alert type of an event.
+type EventAlertType string
+
+// The alert types of events. Events without an alert type are shown as
+// EventAlertTypeInfo.
+const (
+	EventAlertTypeInfo    EventAlertType = "info"
+	EventAlertTypeWarning EventAlertType = "warning"
+	EventAlertTypeError   EventAlertType = "error"
+	EventAlertTypeSuccess EventAlertType = "success"
+)
+
+// EventPriority is the priority of an event.
+type EventPriority string
+
+// The priorities of events. Events without a priority are shown as
+// EventPriorityNormal.
+const (
+	EventPriorityNormal EventPriority = "normal"
+	EventPriorityLow    EventPriority = "low"
+)
+
+// ServiceCheckStatus is the status reported by a service check.
+type ServiceCheckStatus int
+
+// The statuses of service checks.
+const (
+	ServiceCheckOK       ServiceCheckStatus = ServiceCheckStatus(statsd.Ok)
+	ServiceCheckWarning  ServiceCheckStatus = ServiceCheckStatus(statsd.Warn)
+	ServiceCheckCritical ServiceCheckStatus = ServiceCheckStatus(statsd.Critical)
+	ServiceCheckUnknown  ServiceCheckStatus = ServiceCheckStatus(statsd.Unknown)
+)
+
+// WithEventAlertType sets the alert type of an event.
+func WithEventAlertType(alertType EventAlertType) Option {
+	return func(o *options) error {
+		switch alertType {
+		case EventAlertTypeInfo, EventAlertTypeWarning, EventAlertTypeError, EventAlertTypeSuccess:
+			o.alertType = alertType
+			return nil
+		default:
+			return fmt.Errorf("unknown event alert type: %s", alertType)
+		}
+	}
+}
+
+// WithEventPriority sets the priority of an event.
+func WithEventPriority(priority EventPriority) Option {
+	return func(o *options) error {
+		switch priority {
+		case EventPriorityNormal, EventPriorityLow:
+			o.priority = priority
+			return nil
+		default:
+			return fmt.Errorf("unknown event priority: %s", priority)
+		}
+	}
+}
+
+// WithEventAggregationKey sets the aggregation key of an event. Events with
+// the same aggregation key are grouped together.
+func WithEventAggregationKey(key string) Option {
+	return func(o *options) error {
+		o.aggregationKey = key
+		return nil
+	}
+}
+
+// WithEventSourceType sets the source type of an event, such as "kubernetes"
+// or "github".
+func WithEventSourceType(sourceType string) Option {
+	return func(o *options) error {
+		o.sourceType = sourceType
+		return nil
+	}
+}
+
+// WithServiceCheckMessage sets the message of a service check, describing
+// the status.
+func WithServiceCheckMessage(message string) Option {
+	return func(o *options) error {
+		o.message = message
+		return nil
+	}
+}
+
+// WithHostname sets the hostname of an event or service check. When passed
+// to GlobalSetup or NewClient, it sets the default for every event and service
+// check.
+func WithHostname(hostname string) Option {
+	return func(o *options) error {
+		o.hostname = hostname
+		return nil
+	}
+}
+
+// Event sends an event with the title and text, such as a deploy or an
+// incident. Use WithEventAlertType, WithEventPriority,
+// WithEventAggregationKey, WithEventSourceType, WithHostname and WithTag to
+// set the other fields of the event.
+func Event(title, text string, options ...Option) {
+	DefaultClient().Event(title, text, options...)
+}
+
+// EventCtx is like Event, but also adds the tags carried by ctx. See ContextWithTags.
+func EventCtx(ctx context.Context, title, text string, options ...Option) {
+	DefaultClient().EventCtx(ctx, title, text, options...)
+}
+
+// ServiceCheck sends the status of the service check name. Use
+// WithServiceCheckMessage, WithHostname and WithTag to set the other fields
+// of the service check.
+func ServiceCheck(name string, status ServiceCheckStatus, options ...Option) {
+	DefaultClient().ServiceCheck(name, status, options...)
+}
+
+// ServiceCheckCtx is like ServiceCheck, but also adds the tags carried by ctx. See ContextWithTags.
+func ServiceCheckCtx(ctx context.Context, name string, status ServiceCheckStatus, options ...Option) {
+	DefaultClient().ServiceCheckCtx(ctx, name, status, options...)
+}
+
+// Event sends an event with the title and text. See the package-level Event.
+func (c *Client) Event(title, text string, options ...Option) {
+	c.EventCtx(context.Background(), title, text, options...)
+}
+
+// EventCtx is like Event, but also adds the tags carried by ctx. See ContextWithTags.
+func (c *Client) EventCtx(ctx context.Context, title, text string, options ...Option) {
+	localOpts, err := c.resolveLocalOptsWithoutLimits(ctx, options)
+	if err != nil {
+		localOpts.errorHandler(fmt.Errorf("failed to apply event options: %w", err))
+		return
+	}
+	if title == "" {
+		localOpts.errorHandler(fmt.Errorf("failed to send Event: title cannot be empty"))
+		return
+	}
+	err = c.statsd.Event(&statsd.Event{
+		Title:          title,
+		Text:           text,
+		Hostname:       localOpts.hostname,
+		AggregationKey: localOpts.aggregationKey,
+		Priority:       statsd.EventPriority(localOpts.priority),
+		SourceTypeName: localOpts.sourceType,
+		AlertType:      statsd.EventAlertType(localOpts.alertType),
+		Tags:           localOpts.tags,
+	})
+	if err != nil {
+		localOpts.errorHandler(fmt.Errorf("failed to send Event: %w", err))
+	}
+}
+
+// ServiceCheck sends the status of the service check name. See the
+// package-level ServiceCheck.
+func (c *Client) ServiceCheck(name string, status ServiceCheckStatus, options ...Option) {
+	c.ServiceCheckCtx(context.Background(), name, status, options...)
+}
+
+// ServiceCheckCtx is like ServiceCheck, but also adds the tags carried by ctx. See ContextWithTags.
+func (c *Client) ServiceCheckCtx(ctx context.Context, name string, status ServiceCheckStatus, options ...Option) {
+	localOpts, err := c.resolveLocalOptsWithoutLimits(ctx, options)
+	if err != nil {
+		localOpts.errorHandler(fmt.Errorf("failed to apply service check options: %w", err))
+		return
+	}
+	if name == "" {
+		localOpts.errorHandler(fmt.Errorf("failed to send ServiceCheck: name cannot be empty"))
+		return
+	}
+	if status < ServiceCheckOK || status > ServiceCheckUnknown {
+		localOpts.errorHandler(fmt.Errorf("failed to send ServiceCheck: unknown status: %d", status))
+		return
+	}
+	err = c.statsd.ServiceCheck(&statsd.ServiceCheck{
+		Name:     name,
+		Status:   statsd.ServiceCheckStatus(status),
+		Hostname: localOpts.hostname,
+		Message:  localOpts.message,
+		Tags:     localOpts.tags,
+	})
+	if err != nil {
+		localOpts.errorHandler(fmt.Errorf("failed to send ServiceCheck: %w", err))
+	}
+}
@@ -0,0 +1,109 @@
+package metrics_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/DataDog/datadog-go/v5/statsd"
+	"github.com/coopnorge/go-datadog-lib/v2/ddtest"
+	"github.com/coopnorge/go-datadog-lib/v2/metrics"
+	"github.com/coopnorge/go-datadog-lib/v2/metrics/metricstest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEvent(t *testing.T) {
+	recorder := metricstest.Start(t)
+
+	ctx := metrics.ContextWithTags(context.Background(), "team", "checkout")
+	metrics.EventCtx(ctx, "Deployed checkout", "Version v1.2.3 was deployed",
+		metrics.WithEventAlertType(metrics.EventAlertTypeSuccess),
+		metrics.WithEventPriority(metrics.EventPriorityLow),
+		metrics.WithEventAggregationKey("deploy-checkout"),
+		metrics.WithEventSourceType("kubernetes"),
+		metrics.WithHostname("node-1"),
+		metrics.WithTag("cluster", "prod"),
+	)
+
+	events := recorder.Events()
+	require.Len(t, events, 1)
+	assert.Equal(t, statsd.Event{
+		Title:          "Deployed checkout",
+		Text:           "Version v1.2.3 was deployed",
+		Hostname:       "node-1",
+		AggregationKey: "deploy-checkout",
+		Priority:       statsd.Low,
+		SourceTypeName: "kubernetes",
+		AlertType:      statsd.Success,
+		Tags:           []string{"cluster:prod", "team:checkout"},
+	}, events[0])
+}
+
+func TestServiceCheck(t *testing.T) {
+	recorder := metricstest.Start(t)
+
+	metrics.ServiceCheck("checkout.database", metrics.ServiceCheckCritical,
+		metrics.WithServiceCheckMessage("connection refused"),
+		metrics.WithTag("database", "orders"),
+	)
+
+	serviceChecks := recorder.ServiceChecks()
+	require.Len(t, serviceChecks, 1)
+	assert.Equal(t, "checkout.database", serviceChecks[0].Name)
+	assert.Equal(t, statsd.Critical, serviceChecks[0].Status)
+	assert.Equal(t, "connection refused", serviceChecks[0].Message)
+	assert.Equal(t, []string{"database:orders"}, serviceChecks[0].Tags)
+}
+
+func TestEventAndServiceCheckErrors(t *testing.T) {
+	recorder := metricstest.Start(t)
+
+	tests := []struct {
+		name string
+		send func(errorHandler metrics.Option)
+	}{
+		{"Event without title", func(o metrics.Option) { metrics.Event("", "text", o) }},
+		{"Event with unknown alert type", func(o metrics.Option) {
+			metrics.Event("title", "text", o, metrics.WithEventAlertType("fatal"))
+		}},
+		{"Event with unknown priority", func(o metrics.Option) {
+			metrics.Event("title", "text", o, metrics.WithEventPriority("high"))
+		}},
+		{"Service check without name", func(o metrics.Option) { metrics.ServiceCheck("", metrics.ServiceCheckOK, o) }},
+		{"Service check with unknown status", func(o metrics.Option) { metrics.ServiceCheck("check", 4, o) }},
+		{"Service check with invalid tag", func(o metrics.Option) {
+			metrics.ServiceCheck("check", metrics.ServiceCheckOK, o, metrics.WithTag("service", "foo"))
+		}},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var errs []error
+			tc.send(metrics.WithErrorHandler(func(err error) { errs = append(errs, err) }))
+			assert.Len(t, errs, 1)
+		})
+	}
+	assert.Empty(t, recorder.Events())
+	assert.Empty(t, recorder.ServiceChecks())
+}
+
+func TestClientEventAndServiceCheck(t *testing.T) {
+	agent := ddtest.StartAgent(t)
+
+	client, err := metrics.NewClient(
+		metrics.WithErrorHandler(func(err error) { t.Error(err) }),
+		metrics.WithHostname("node-1"),
+	)
+	require.NoError(t, err)
+
+	client.Event("Incident opened", "Checkout is down", metrics.WithEventAlertType(metrics.EventAlertTypeError))
+	client.ServiceCheck("checkout.health", metrics.ServiceCheckOK)
+	require.NoError(t, client.Close())
+
+	e := agent.RequireEvent(t, "Incident opened")
+	assert.Equal(t, "error", e.AlertType)
+	assert.Equal(t, "node-1", e.Hostname)
+
+	sc := agent.RequireServiceCheck(t, "checkout.health")
+	assert.Equal(t, 0, sc.Status)
+	assert.Equal(t, "node-1", sc.Hostname)
+}
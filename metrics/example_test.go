@@ -32,3 +32,57 @@ func run() error {
 
 	return nil
 }
+
+func ExampleNewClient() {
+	client, err := metrics.NewClient(metrics.WithSampleRate(0.5))
+	if err != nil {
+		panic(err)
+	}
+	defer func() {
+		err := client.Close()
+		if err != nil {
+			panic(err)
+		}
+	}()
+
+	client.Incr("my-metric")
+	client.Gauge("gauge.with.options", 42.0, metrics.WithTag("tag1", "test1"))
+}
+
+func ExampleStartTimer() {
+	timer := metrics.StartTimer("checkout.duration", metrics.WithTag("step", "payment"))
+	defer timer.Stop()
+
+	// ...
+}
+
+func ExampleMeasure() {
+	err := metrics.Measure("inventory.lookup", func() error {
+		// ...
+		return nil
+	})
+	if err != nil {
+		panic(err)
+	}
+}
+
+func ExampleNewCounter() {
+	orders, err := metrics.NewCounter("checkout.orders", "channel", "status")
+	if err != nil {
+		panic(err)
+	}
+
+	orders.Incr("web", "completed")
+}
+
+func ExampleRegisterGaugeFunc() {
+	queue := make(chan string, 100)
+
+	unregister, err := metrics.RegisterGaugeFunc("orders.queue.depth", func() float64 {
+		return float64(len(queue))
+	}, metrics.WithTag("queue", "orders"))
+	if err != nil {
+		panic(err)
+	}
+	defer unregister()
+}
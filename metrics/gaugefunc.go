@@ -0,0 +1,173 @@
+package metrics
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"sync"
+	"time"
+)
+
+const defaultGaugePollInterval = 10 * time.Second
+
+// GaugeSeries is the value of a single series of a gauge registered with
+// RegisterGaugeSeriesFunc.
+type GaugeSeries struct {
+	// Tags identifies the series, in addition to the options the gauge was
+	// registered with.
+	Tags  map[string]string
+	Value float64
+}
+
+// gaugeFunc is a registered callback-based gauge.
+type gaugeFunc struct {
+	name    string
+	poll    func() []GaugeSeries
+	options []Option
+}
+
+// gaugePoller polls the registered gauges on an interval, and sends their
+// values.
+type gaugePoller struct {
+	mu     sync.Mutex
+	gauges map[*gaugeFunc]struct{}
+
+	// client returns the Client to send the values with.
+	client func() *Client
+
+	stopOnce sync.Once
+	stopCh   chan struct{}
+	wg       sync.WaitGroup
+}
+
+// globalGaugePoller polls the gauges registered with RegisterGaugeFunc and
+// RegisterGaugeSeriesFunc. It is started by GlobalSetup, and stopped by
+// GlobalShutdown.
+var globalGaugePoller = newGaugePoller(DefaultClient)
+
+func newGaugePoller(client func() *Client) *gaugePoller {
+	return &gaugePoller{
+		gauges: map[*gaugeFunc]struct{}{},
+		client: client,
+		stopCh: make(chan struct{}),
+	}
+}
+
+// RegisterGaugeFunc registers a gauge for the metric name, whose value is
+// polled from fn on an interval, and sent with the options. The interval is
+// set with WithGaugePollInterval, and defaults to 10 seconds. Gauges are
+// polled once GlobalSetup has been called, and a final time when
+// GlobalShutdown is called. fn is called from a background goroutine, so it
+// must be safe for concurrent use.
+//
+// The returned function unregisters the gauge.
+func RegisterGaugeFunc(name string, fn func() float64, options ...Option) (unregister func(), err error) {
+	if fn == nil {
+		return nil, fmt.Errorf("gauge func for metric %s cannot be nil", name)
+	}
+	return RegisterGaugeSeriesFunc(name, func() []GaugeSeries {
+		return []GaugeSeries{{Value: fn()}}
+	}, options...)
+}
+
+// RegisterGaugeSeriesFunc is like RegisterGaugeFunc, but fn returns the
+// values of several series of the gauge, such as the length of each queue.
+func RegisterGaugeSeriesFunc(name string, fn func() []GaugeSeries, options ...Option) (unregister func(), err error) {
+	err = validateMetricName(name)
+	if err != nil {
+		return nil, err
+	}
+	if fn == nil {
+		return nil, fmt.Errorf("gauge func for metric %s cannot be nil", name)
+	}
+	return globalGaugePoller.register(&gaugeFunc{name: name, poll: fn, options: options}), nil
+}
+
+// WithGaugePollInterval sets the interval that gauges registered with
+// RegisterGaugeFunc and RegisterGaugeSeriesFunc are polled on. Defaults to 10
+// seconds. WithGaugePollInterval only has an effect when passed to
+// GlobalSetup.
+func WithGaugePollInterval(interval time.Duration) Option {
+	return func(o *options) error {
+		if interval <= 0 {
+			return fmt.Errorf("gauge poll interval must be positive: %s", interval)
+		}
+		o.gaugePollInterval = interval
+		return nil
+	}
+}
+
+func (p *gaugePoller) register(g *gaugeFunc) func() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.gauges[g] = struct{}{}
+	return func() {
+		p.mu.Lock()
+		defer p.mu.Unlock()
+		delete(p.gauges, g)
+	}
+}
+
+// start polls the gauges on the interval, until stop is called.
+func (p *gaugePoller) start(interval time.Duration) {
+	p.wg.Go(func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-p.stopCh:
+				return
+			case <-ticker.C:
+				p.poll()
+			}
+		}
+	})
+}
+
+// stop stops polling, and polls the gauges a final time, so that their last
+// values are sent before the client is flushed.
+func (p *gaugePoller) stop() {
+	p.stopOnce.Do(func() {
+		close(p.stopCh)
+		p.wg.Wait()
+		p.poll()
+	})
+}
+
+// poll sends the current value of every registered gauge.
+func (p *gaugePoller) poll() {
+	p.mu.Lock()
+	gauges := slices.Collect(maps.Keys(p.gauges))
+	p.mu.Unlock()
+
+	client := p.client()
+	for _, g := range gauges {
+		series, err := g.safePoll()
+		if err != nil {
+			// The options may override the error handler. Errors from
+			// applying them are reported when the gauge is sent.
+			localOpts := client.getLocalOpts()
+			_ = localOpts.applyOptions(g.options) //nolint:errcheck
+			localOpts.errorHandler(err)
+			continue
+		}
+		for _, s := range series {
+			options := slices.Clone(g.options)
+			for _, k := range slices.Sorted(maps.Keys(s.Tags)) {
+				options = append(options, WithTag(k, s.Tags[k]))
+			}
+			client.Gauge(g.name, s.Value, options...)
+		}
+	}
+}
+
+// safePoll calls the gauge's callback, and returns an error if it panics,
+// since a panic in the background goroutine would crash the application.
+func (g *gaugeFunc) safePoll() (series []GaugeSeries, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("gauge func for metric %s panicked: %v", g.name, r)
+		}
+	}()
+	return g.poll(), nil
+}
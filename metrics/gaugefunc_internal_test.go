@@ -0,0 +1,133 @@
+package metrics //nolint:revive
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/DataDog/datadog-go/v5/statsd"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// gaugeRecorder is a statsd.ClientInterface that records gauges.
+type gaugeRecorder struct {
+	statsd.NoOpClient
+
+	mu     sync.Mutex
+	gauges map[string]float64
+}
+
+func (r *gaugeRecorder) Gauge(name string, value float64, tags []string, _ float64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.gauges == nil {
+		r.gauges = map[string]float64{}
+	}
+	key := name
+	for _, tag := range tags {
+		key += "," + tag
+	}
+	r.gauges[key] = value
+	return nil
+}
+
+func (r *gaugeRecorder) get(key string) (float64, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	v, ok := r.gauges[key]
+	return v, ok
+}
+
+func TestGaugePoller(t *testing.T) {
+	recorder := &gaugeRecorder{}
+	var errs []error
+	var errsMu sync.Mutex
+	client := &Client{
+		statsd: recorder,
+		opts: &options{
+			errorHandler: func(err error) {
+				errsMu.Lock()
+				defer errsMu.Unlock()
+				errs = append(errs, err)
+			},
+			sampleRate: 1.0,
+		},
+	}
+	poller := newGaugePoller(func() *Client { return client })
+
+	var mu sync.Mutex
+	depth := 1.0
+	poller.register(&gaugeFunc{
+		name: "queue.depth",
+		poll: func() []GaugeSeries {
+			mu.Lock()
+			defer mu.Unlock()
+			return []GaugeSeries{{Value: depth}}
+		},
+		options: []Option{WithTag("queue", "orders")},
+	})
+	poller.register(&gaugeFunc{
+		name: "pool.connections",
+		poll: func() []GaugeSeries {
+			return []GaugeSeries{
+				{Tags: map[string]string{"state": "idle"}, Value: 3},
+				{Tags: map[string]string{"state": "in_use"}, Value: 7},
+			}
+		},
+	})
+	unregister := poller.register(&gaugeFunc{
+		name: "unregistered",
+		poll: func() []GaugeSeries { return []GaugeSeries{{Value: 1}} },
+	})
+	unregister()
+	poller.register(&gaugeFunc{
+		name: "panics",
+		poll: func() []GaugeSeries { panic("boom") },
+	})
+
+	poller.start(time.Millisecond)
+	assert.Eventually(t, func() bool {
+		v, ok := recorder.get("queue.depth,queue:orders")
+		return ok && v == 1
+	}, time.Second, time.Millisecond)
+
+	mu.Lock()
+	depth = 2
+	mu.Unlock()
+	poller.stop()
+
+	v, _ := recorder.get("queue.depth,queue:orders")
+	assert.Equal(t, 2.0, v, "the last value should be polled when stopping")
+	v, _ = recorder.get("pool.connections,state:idle")
+	assert.Equal(t, 3.0, v)
+	v, _ = recorder.get("pool.connections,state:in_use")
+	assert.Equal(t, 7.0, v)
+	_, ok := recorder.get("unregistered")
+	assert.False(t, ok)
+
+	errsMu.Lock()
+	defer errsMu.Unlock()
+	require.NotEmpty(t, errs)
+	assert.ErrorContains(t, errs[0], "gauge func for metric panics panicked: boom")
+
+	// Stopping again must not poll or block.
+	poller.stop()
+}
+
+// TestGaugePollerWhileDefaultClientIsReplaced is meant to be run with -race,
+// as tests replace the default client while the poller started by
+// GlobalSetup reads it.
+func TestGaugePollerWhileDefaultClientIsReplaced(t *testing.T) {
+	p := newGaugePoller(DefaultClient)
+	p.register(&gaugeFunc{name: "queue.depth", poll: func() []GaugeSeries { return []GaugeSeries{{Value: 1}} }})
+	p.start(time.Millisecond)
+
+	recorder := &gaugeRecorder{}
+	for range 20 {
+		restore := swapDefaultStatsdClient(recorder)
+		time.Sleep(time.Millisecond)
+		restore()
+	}
+	p.stop()
+}
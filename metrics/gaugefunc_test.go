@@ -0,0 +1,26 @@
+package metrics_test
+
+import (
+	"testing"
+
+	"github.com/coopnorge/go-datadog-lib/v2/metrics"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegisterGaugeFunc(t *testing.T) {
+	unregister, err := metrics.RegisterGaugeFunc("cache.size", func() float64 { return 1 })
+	require.NoError(t, err)
+	unregister()
+
+	unregister, err = metrics.RegisterGaugeSeriesFunc("queue.depth", func() []metrics.GaugeSeries { return nil })
+	require.NoError(t, err)
+	unregister()
+
+	_, err = metrics.RegisterGaugeFunc("", func() float64 { return 1 })
+	assert.Error(t, err)
+	_, err = metrics.RegisterGaugeFunc("cache.size", nil)
+	assert.Error(t, err)
+	_, err = metrics.RegisterGaugeSeriesFunc("queue.depth", nil)
+	assert.Error(t, err)
+}
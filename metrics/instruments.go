@@ -0,0 +1,255 @@
+package metrics
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// maxMetricNameLength is the maximum length of a metric name accepted by
+// Datadog.
+const maxMetricNameLength = 200
+
+var (
+	schemasMu sync.Mutex
+	// schemas holds the kind and tag keys of every declared instrument, by
+	// metric name, so that a metric cannot be declared with two different tag
+	// schemas.
+	schemas = map[string]instrumentSchema{}
+)
+
+type instrumentKind string
+
+const (
+	instrumentKindCounter      instrumentKind = "counter"
+	instrumentKindGauge        instrumentKind = "gauge"
+	instrumentKindDistribution instrumentKind = "distribution"
+)
+
+type instrumentSchema struct {
+	kind    instrumentKind
+	tagKeys []string
+}
+
+// instrument is the part shared by every typed instrument.
+type instrument struct {
+	// client is nil for instruments created with the package-level
+	// constructors, which use the default client at the time of sending, so
+	// that instruments can be declared before GlobalSetup is called.
+	client  *Client
+	name    string
+	tagKeys []string
+}
+
+// CounterMetric is a Count metric with a fixed set of required tag keys.
+// Create a CounterMetric with NewCounter.
+type CounterMetric struct {
+	instrument
+}
+
+// GaugeMetric is a Gauge metric with a fixed set of required tag keys.
+// Create a GaugeMetric with NewGauge.
+type GaugeMetric struct {
+	instrument
+}
+
+// DistributionMetric is a Distribution metric with a fixed set of required tag
+// keys. Create a DistributionMetric with NewDistribution.
+type DistributionMetric struct {
+	instrument
+}
+
+// NewCounter declares a CounterMetric for the metric name, that requires a value for
+// each of the tag keys, in order, whenever it is incremented. The name and tag
+// keys are validated once, and an error is returned if the metric has already
+// been declared with another kind or other tag keys.
+func NewCounter(name string, tagKeys ...string) (*CounterMetric, error) {
+	return newCounter(nil, name, tagKeys)
+}
+
+// NewGauge declares a GaugeMetric for the metric name, that requires a value for
+// each of the tag keys, in order, whenever it is recorded. See NewCounter.
+func NewGauge(name string, tagKeys ...string) (*GaugeMetric, error) {
+	return newGauge(nil, name, tagKeys)
+}
+
+// NewDistribution declares a DistributionMetric for the metric name, that
+// requires a value for each of the tag keys, in order, whenever it is
+// recorded. See NewCounter.
+func NewDistribution(name string, tagKeys ...string) (*DistributionMetric, error) {
+	return newDistribution(nil, name, tagKeys)
+}
+
+// NewCounter is like the package-level NewCounter, but the CounterMetric
+// sends with c.
+func (c *Client) NewCounter(name string, tagKeys ...string) (*CounterMetric, error) {
+	return newCounter(c, name, tagKeys)
+}
+
+// NewGauge is like the package-level NewGauge, but the GaugeMetric sends
+// with c.
+func (c *Client) NewGauge(name string, tagKeys ...string) (*GaugeMetric, error) {
+	return newGauge(c, name, tagKeys)
+}
+
+// NewDistribution is like the package-level NewDistribution, but the
+// DistributionMetric sends with c.
+func (c *Client) NewDistribution(name string, tagKeys ...string) (*DistributionMetric, error) {
+	return newDistribution(c, name, tagKeys)
+}
+
+func newCounter(c *Client, name string, tagKeys []string) (*CounterMetric, error) {
+	i, err := newInstrument(c, instrumentKindCounter, name, tagKeys)
+	if err != nil {
+		return nil, err
+	}
+	return &CounterMetric{instrument: i}, nil
+}
+
+func newGauge(c *Client, name string, tagKeys []string) (*GaugeMetric, error) {
+	i, err := newInstrument(c, instrumentKindGauge, name, tagKeys)
+	if err != nil {
+		return nil, err
+	}
+	return &GaugeMetric{instrument: i}, nil
+}
+
+func newDistribution(c *Client, name string, tagKeys []string) (*DistributionMetric, error) {
+	i, err := newInstrument(c, instrumentKindDistribution, name, tagKeys)
+	if err != nil {
+		return nil, err
+	}
+	return &DistributionMetric{instrument: i}, nil
+}
+
+func newInstrument(c *Client, kind instrumentKind, name string, tagKeys []string) (instrument, error) {
+	err := validateMetricName(name)
+	if err != nil {
+		return instrument{}, err
+	}
+	for i, k := range tagKeys {
+		err := validateTagKey(k)
+		if err != nil {
+			return instrument{}, fmt.Errorf("invalid tag key for metric %q: %w", name, err)
+		}
+		if slices.Contains(tagKeys[:i], k) {
+			return instrument{}, fmt.Errorf("duplicate tag key %q for metric %q", k, name)
+		}
+	}
+	tagKeys = slices.Clone(tagKeys)
+
+	schemasMu.Lock()
+	defer schemasMu.Unlock()
+	if existing, ok := schemas[name]; ok {
+		if existing.kind != kind || !slices.Equal(existing.tagKeys, tagKeys) {
+			return instrument{}, fmt.Errorf("metric %q is already declared as a %s with tag keys %v", name, existing.kind, existing.tagKeys)
+		}
+	} else {
+		schemas[name] = instrumentSchema{kind: kind, tagKeys: tagKeys}
+	}
+
+	return instrument{client: c, name: name, tagKeys: tagKeys}, nil
+}
+
+// validateMetricName validates name according to Datadog's naming rules: it
+// must start with a letter, and only contain ASCII alphanumerics, underscores
+// and periods.
+func validateMetricName(name string) error {
+	if name == "" {
+		return fmt.Errorf("metric name cannot be empty")
+	}
+	if len(name) > maxMetricNameLength {
+		return fmt.Errorf("metric name %q exceeds maximum length", name)
+	}
+	if !isASCIILetter(name[0]) {
+		return fmt.Errorf("metric name %q must start with a letter", name)
+	}
+	for i := 0; i < len(name); i++ {
+		ch := name[i]
+		if !isASCIILetter(ch) && !(ch >= '0' && ch <= '9') && ch != '_' && ch != '.' {
+			return fmt.Errorf("metric name %q contains invalid character %q", name, ch)
+		}
+	}
+	return nil
+}
+
+func isASCIILetter(ch byte) bool {
+	return (ch >= 'a' && ch <= 'z') || (ch >= 'A' && ch <= 'Z')
+}
+
+// Add increments the counter by value. A value must be given for every tag key
+// the CounterMetric was declared with, in the same order.
+func (c *CounterMetric) Add(value int64, tagValues ...string) {
+	client := c.resolveClient()
+	tags, err := c.tags(tagValues)
+	if err != nil {
+		client.opts.errorHandler(err)
+		return
+	}
+	client.Count(c.name, value, withValidatedTags(tags))
+}
+
+// Incr increments the counter by 1. See Add.
+func (c *CounterMetric) Incr(tagValues ...string) {
+	c.Add(1, tagValues...)
+}
+
+// Record sets the gauge to value. A value must be given for every tag key the
+// GaugeMetric was declared with, in the same order.
+func (g *GaugeMetric) Record(value float64, tagValues ...string) {
+	client := g.resolveClient()
+	tags, err := g.tags(tagValues)
+	if err != nil {
+		client.opts.errorHandler(err)
+		return
+	}
+	client.Gauge(g.name, value, withValidatedTags(tags))
+}
+
+// Record adds value to the distribution. A value must be given for every tag
+// key the DistributionMetric was declared with, in the same order.
+func (d *DistributionMetric) Record(value float64, tagValues ...string) {
+	client := d.resolveClient()
+	tags, err := d.tags(tagValues)
+	if err != nil {
+		client.opts.errorHandler(err)
+		return
+	}
+	client.Distribution(d.name, value, withValidatedTags(tags))
+}
+
+// Name returns the metric name of the instrument.
+func (i *instrument) Name() string {
+	return i.name
+}
+
+// TagKeys returns the tag keys the instrument was declared with.
+func (i *instrument) TagKeys() []string {
+	return slices.Clone(i.tagKeys)
+}
+
+func (i *instrument) resolveClient() *Client {
+	if i.client != nil {
+		return i.client
+	}
+	return DefaultClient()
+}
+
+// tags pairs the tag values with the declared tag keys, and returns them
+// formatted as "key:value".
+func (i *instrument) tags(tagValues []string) ([]string, error) {
+	if len(tagValues) != len(i.tagKeys) {
+		return nil, fmt.Errorf("metric %q requires %d tag values for the tag keys [%s], got %d", i.name, len(i.tagKeys), strings.Join(i.tagKeys, ", "), len(tagValues))
+	}
+	tags := make([]string, len(tagValues))
+	for idx, v := range tagValues {
+		k := i.tagKeys[idx]
+		err := validateTagValue(k, v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid tag value for metric %q: %w", i.name, err)
+		}
+		tags[idx] = k + ":" + v
+	}
+	return tags, nil
+}
@@ -0,0 +1,92 @@
+package metrics_test
+
+import (
+	"testing"
+
+	"github.com/coopnorge/go-datadog-lib/v2/ddtest"
+	"github.com/coopnorge/go-datadog-lib/v2/metrics"
+	"github.com/coopnorge/go-datadog-lib/v2/metrics/metricstest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInstruments(t *testing.T) {
+	recorder := metricstest.Start(t)
+
+	counter, err := metrics.NewCounter("instruments.orders", "channel", "status")
+	require.NoError(t, err)
+	gauge, err := metrics.NewGauge("instruments.queue.depth", "queue")
+	require.NoError(t, err)
+	distribution, err := metrics.NewDistribution("instruments.order.value")
+	require.NoError(t, err)
+
+	counter.Add(2, "web", "ok")
+	counter.Incr("web", "ok")
+	gauge.Record(7, "payments")
+	distribution.Record(149.9)
+
+	recorder.AssertCount(t, "instruments.orders", 3, "channel:web", "status:ok")
+	recorder.AssertGauge(t, "instruments.queue.depth", 7, "queue:payments")
+	assert.Empty(t, recorder.RequireMetric(t, "instruments.order.value").Tags)
+
+	assert.Equal(t, "instruments.orders", counter.Name())
+	assert.Equal(t, []string{"channel", "status"}, counter.TagKeys())
+}
+
+func TestInstrumentsInvalidDeclaration(t *testing.T) {
+	tests := []struct {
+		name    string
+		metric  string
+		tagKeys []string
+	}{
+		{"Empty name", "", nil},
+		{"Name starting with a digit", "1instruments", nil},
+		{"Name with invalid characters", "instruments-invalid", nil},
+		{"Empty tag key", "instruments.empty.key", []string{""}},
+		{"Reserved tag key", "instruments.reserved.key", []string{"service"}},
+		{"Duplicate tag key", "instruments.duplicate.key", []string{"status", "status"}},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := metrics.NewCounter(tc.metric, tc.tagKeys...)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestInstrumentsSchemaConflict(t *testing.T) {
+	_, err := metrics.NewCounter("instruments.conflict", "status")
+	require.NoError(t, err)
+
+	_, err = metrics.NewCounter("instruments.conflict", "status")
+	assert.NoError(t, err, "redeclaring with the same schema should be allowed")
+
+	_, err = metrics.NewCounter("instruments.conflict", "status", "channel")
+	assert.ErrorContains(t, err, "already declared")
+
+	_, err = metrics.NewGauge("instruments.conflict", "status")
+	assert.ErrorContains(t, err, "already declared")
+}
+
+func TestInstrumentsRejectInvalidTagValues(t *testing.T) {
+	agent := ddtest.StartAgent(t)
+
+	var errs []error
+	client, err := metrics.NewClient(metrics.WithErrorHandler(func(err error) { errs = append(errs, err) }))
+	require.NoError(t, err)
+
+	counter, err := client.NewCounter("instruments.validated", "channel", "status")
+	require.NoError(t, err)
+
+	counter.Add(1, "web")
+	counter.Add(1, "web", "ok", "extra")
+	counter.Add(1, "web", "")
+	counter.Add(1, "web", "ok")
+	require.NoError(t, client.Close())
+
+	assert.Len(t, errs, 3)
+	m := agent.RequireMetric(t, "instruments.validated")
+	assert.True(t, m.HasTag("channel:web"))
+	assert.True(t, m.HasTag("status:ok"))
+	assert.Len(t, agent.MetricsByName("instruments.validated"), 1)
+}
@@ -2,25 +2,68 @@
 package metrics //nolint:revive
 
 import (
-	"fmt"
+	"context"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/DataDog/datadog-go/v5/statsd"
 	"github.com/coopnorge/go-datadog-lib/v2/internal"
+	"github.com/coopnorge/go-datadog-lib/v2/internal/seam"
 )
 
 var (
 	setupOnce sync.Once
 	setupErr  error
 
-	// init should initialize the global variables with instances that does not cause panic.
-	// These values should only be used when called from unit-testing code that does not want to set environment-variables and call `GlobalSetup`.
+	// defaultClient is initialized with an instance that does not cause panic.
+	// This value should only be used when called from unit-testing code that does not want to set environment-variables and call `GlobalSetup`.
 	// Any calls to `GlobalSetup` will override this no-op client.
-	statsdClient statsd.ClientInterface = &statsd.NoOpClient{}
-	globalOpts                          = defaultOptions()
+	// The client is replaced by tests while the gauge poller may be reading
+	// it, so it is only accessed through DefaultClient and setDefaultClient.
+	defaultClient atomic.Pointer[Client]
 )
 
+func init() {
+	defaultClient.Store(newNoOpClient())
+	seam.SwapDefaultStatsdClient = swapDefaultStatsdClient
+	seam.SetupDefaultClient = setupDefaultClient
+}
+
+// swapDefaultStatsdClient replaces the default client with one that keeps the
+// current options, but sends to c. The returned function restores the
+// previous default client.
+func swapDefaultStatsdClient(c statsd.ClientInterface) func() {
+	current := DefaultClient()
+	previous := setDefaultClient(&Client{statsd: c, opts: current.opts, cardinality: newCardinalityGuard(current.opts)})
+	return func() {
+		setDefaultClient(previous)
+	}
+}
+
+// setDefaultClient replaces the Client used by the package-level functions,
+// and returns the previous one.
+func setDefaultClient(c *Client) *Client {
+	return defaultClient.Swap(c)
+}
+
+// setupDefaultClient replaces the default client with a new one configured
+// from the environment. Unlike GlobalSetup, it can be called more than once,
+// so that every test can point the package-level functions at its own agent.
+// The returned function closes the new client, and restores the previous
+// default client.
+func setupDefaultClient() (func(), error) {
+	client, err := NewClient()
+	if err != nil {
+		return nil, err
+	}
+	previous := setDefaultClient(client)
+	return func() {
+		setDefaultClient(previous)
+		_ = client.Close()
+	}, nil
+}
+
 // GlobalSetup configures the Dogstatsd Client. GlobalSetup is intended to be
 // called from coopdatadog.Start(), but can be called directly.
 func GlobalSetup(options ...Option) error {
@@ -30,147 +73,135 @@ func GlobalSetup(options ...Option) error {
 			return
 		}
 
-		globalOpts, setupErr = resolveOptions(options)
-		if setupErr != nil {
-			return
-		}
-
-		statsdClient, setupErr = statsd.New(globalOpts.dsdEndpoint, statsd.WithTags(globalOpts.tags))
+		var client *Client
+		client, setupErr = NewClient(options...)
 		if setupErr != nil {
 			return
 		}
+		setDefaultClient(client)
+		globalGaugePoller.start(client.opts.gaugePollInterval)
 	})
 	return setupErr
 }
 
+// GlobalShutdown stops polling the gauges registered with RegisterGaugeFunc
+// and RegisterGaugeSeriesFunc, sends their last values, and flushes the
+// Dogstatsd Client. GlobalShutdown is intended to be called from the StopFunc
+// returned by coopdatadog.Start(), but can be called directly. Gauges are not
+// polled again after GlobalShutdown has been called.
+func GlobalShutdown() error {
+	globalGaugePoller.stop()
+	return Flush()
+}
+
 // Flush forces a flush of all the queued dogstatsd payloads.
 func Flush() error {
-	err := statsdClient.Flush()
-	if err != nil {
-		return fmt.Errorf("failed to flush: %w", err)
-	}
-	return nil
+	return DefaultClient().Flush()
 }
 
 // Gauge measures the value of a metric at a particular time.
 func Gauge(name string, value float64, options ...Option) {
-	localOpts := getLocalOpts()
-	err := localOpts.applyOptions(options)
-	if err != nil {
-		localOpts.errorHandler(fmt.Errorf("failed to apply metric options: %w", err))
-		return
-	}
-	err = statsdClient.Gauge(name, value, localOpts.tags, localOpts.sampleRate)
-	if err != nil {
-		localOpts.errorHandler(fmt.Errorf("failed to send Gauge: %w", err))
-	}
+	DefaultClient().Gauge(name, value, options...)
+}
+
+// GaugeCtx is like Gauge, but also adds the tags carried by ctx. See ContextWithTags.
+func GaugeCtx(ctx context.Context, name string, value float64, options ...Option) {
+	DefaultClient().GaugeCtx(ctx, name, value, options...)
 }
 
 // Count tracks how many times something happened per second.
 func Count(name string, value int64, options ...Option) {
-	localOpts := getLocalOpts()
-	err := localOpts.applyOptions(options)
-	if err != nil {
-		localOpts.errorHandler(fmt.Errorf("failed to apply metric options: %w", err))
-		return
-	}
-	err = statsdClient.Count(name, value, localOpts.tags, localOpts.sampleRate)
-	if err != nil {
-		localOpts.errorHandler(fmt.Errorf("failed to to send Count: %w", err))
-	}
+	DefaultClient().Count(name, value, options...)
+}
+
+// CountCtx is like Count, but also adds the tags carried by ctx. See ContextWithTags.
+func CountCtx(ctx context.Context, name string, value int64, options ...Option) {
+	DefaultClient().CountCtx(ctx, name, value, options...)
 }
 
 // Histogram tracks the statistical distribution of a set of values on each host.
 func Histogram(name string, value float64, options ...Option) {
-	localOpts := getLocalOpts()
-	err := localOpts.applyOptions(options)
-	if err != nil {
-		localOpts.errorHandler(fmt.Errorf("failed to apply metric options: %w", err))
-		return
-	}
-	err = statsdClient.Histogram(name, value, localOpts.tags, localOpts.sampleRate)
-	if err != nil {
-		localOpts.errorHandler(fmt.Errorf("failed to to send Histogram: %w", err))
-	}
+	DefaultClient().Histogram(name, value, options...)
+}
+
+// HistogramCtx is like Histogram, but also adds the tags carried by ctx. See ContextWithTags.
+func HistogramCtx(ctx context.Context, name string, value float64, options ...Option) {
+	DefaultClient().HistogramCtx(ctx, name, value, options...)
 }
 
 // Distribution tracks the statistical distribution of a set of values across your infrastructure.
 func Distribution(name string, value float64, options ...Option) {
-	localOpts := getLocalOpts()
-	err := localOpts.applyOptions(options)
-	if err != nil {
-		localOpts.errorHandler(fmt.Errorf("failed to apply metric options: %w", err))
-		return
-	}
-	err = statsdClient.Distribution(name, value, localOpts.tags, localOpts.sampleRate)
-	if err != nil {
-		localOpts.errorHandler(fmt.Errorf("failed to to send Distribution: %w", err))
-	}
+	DefaultClient().Distribution(name, value, options...)
+}
+
+// DistributionCtx is like Distribution, but also adds the tags carried by ctx. See ContextWithTags.
+func DistributionCtx(ctx context.Context, name string, value float64, options ...Option) {
+	DefaultClient().DistributionCtx(ctx, name, value, options...)
 }
 
 // Decr is just Count of -1
 func Decr(name string, options ...Option) {
-	Count(name, -1, options...)
+	DefaultClient().Decr(name, options...)
+}
+
+// DecrCtx is just CountCtx of -1
+func DecrCtx(ctx context.Context, name string, options ...Option) {
+	DefaultClient().DecrCtx(ctx, name, options...)
 }
 
 // Incr is just Count of 1
 func Incr(name string, options ...Option) {
-	Count(name, 1, options...)
+	DefaultClient().Incr(name, options...)
+}
+
+// IncrCtx is just CountCtx of 1
+func IncrCtx(ctx context.Context, name string, options ...Option) {
+	DefaultClient().IncrCtx(ctx, name, options...)
 }
 
 // Set counts the number of unique elements in a group.
 func Set(name string, value string, options ...Option) {
-	localOpts := getLocalOpts()
-	err := localOpts.applyOptions(options)
-	if err != nil {
-		localOpts.errorHandler(fmt.Errorf("failed to apply metric options: %w", err))
-		return
-	}
-	err = statsdClient.Set(name, value, localOpts.tags, localOpts.sampleRate)
-	if err != nil {
-		localOpts.errorHandler(fmt.Errorf("failed to to send Set: %w", err))
-	}
+	DefaultClient().Set(name, value, options...)
+}
+
+// SetCtx is like Set, but also adds the tags carried by ctx. See ContextWithTags.
+func SetCtx(ctx context.Context, name string, value string, options ...Option) {
+	DefaultClient().SetCtx(ctx, name, value, options...)
 }
 
 // Timing sends timing information, it is an alias for TimeInMilliseconds
 func Timing(name string, value time.Duration, options ...Option) {
-	TimeInMilliseconds(name, value.Seconds()*1000, options...)
+	DefaultClient().Timing(name, value, options...)
+}
+
+// TimingCtx sends timing information, it is an alias for TimeInMillisecondsCtx
+func TimingCtx(ctx context.Context, name string, value time.Duration, options ...Option) {
+	DefaultClient().TimingCtx(ctx, name, value, options...)
 }
 
 // TimeInMilliseconds sends timing information in milliseconds.
 func TimeInMilliseconds(name string, value float64, options ...Option) {
-	localOpts := getLocalOpts()
-	err := localOpts.applyOptions(options)
-	if err != nil {
-		localOpts.errorHandler(fmt.Errorf("failed to apply metric options: %w", err))
-		return
-	}
-	err = statsdClient.TimeInMilliseconds(name, value, localOpts.tags, localOpts.sampleRate)
-	if err != nil {
-		localOpts.errorHandler(fmt.Errorf("failed to to send TimeInMilliseconds: %w", err))
-	}
+	DefaultClient().TimeInMilliseconds(name, value, options...)
+}
+
+// TimeInMillisecondsCtx is like TimeInMilliseconds, but also adds the tags carried by ctx. See ContextWithTags.
+func TimeInMillisecondsCtx(ctx context.Context, name string, value float64, options ...Option) {
+	DefaultClient().TimeInMillisecondsCtx(ctx, name, value, options...)
 }
 
 // SimpleEvent sends an event with the provided title and text.
 func SimpleEvent(title, text string) {
-	err := statsdClient.SimpleEvent(title, text)
-	if err != nil {
-		globalOpts.errorHandler(fmt.Errorf("failed to send Event: %w", err))
-	}
+	DefaultClient().SimpleEvent(title, text)
 }
 
-// getLocalOpts will return a copy of the global options, with a few modifications. New "Option"'s can be applied without mutating the global options.
-func getLocalOpts() *options {
-	return &options{
-		errorHandler: globalOpts.errorHandler,
-		sampleRate:   globalOpts.sampleRate,
-		tags:         nil, // Note: We are not copying the global tags, since they have already been passed to the StatsD-client.
-	}
+// DefaultClient returns the Client used by the package-level functions.
+func DefaultClient() *Client {
+	return defaultClient.Load()
 }
 
 // GlobalClient is allows to grab the client so that the legacy codebases
 // using a statsd.Client can proceed to migrate and for those edge cases
 // where the package requires hand metric sending.
 func GlobalClient() statsd.ClientInterface {
-	return statsdClient
+	return DefaultClient().statsd
 }
@@ -4,14 +4,13 @@ import (
 	"fmt"
 	"testing"
 
+	"github.com/DataDog/datadog-go/v5/statsd"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
 
 // This test verifies that we can set a global error-handler, but then override it on a per-metric basis.
 func TestOverrideGlobalValues(t *testing.T) {
-	oldGlobalOpts := globalOpts
-	t.Cleanup(func() { globalOpts = oldGlobalOpts })
 
 	optionThatCausesError := Option(func(*options) error { return fmt.Errorf("always return error") })
 
@@ -21,7 +20,11 @@ func TestOverrideGlobalValues(t *testing.T) {
 	localCount := 0
 	localErrHandler := func(_ error) { localCount++ }
 
-	globalOpts = &options{errorHandler: globalErrHandler, sampleRate: 1.0}
+	oldDefaultClient := setDefaultClient(&Client{
+		statsd: &statsd.NoOpClient{},
+		opts:   &options{errorHandler: globalErrHandler, sampleRate: 1.0},
+	})
+	t.Cleanup(func() { setDefaultClient(oldDefaultClient) })
 
 	Gauge("some_metric", 1.0, optionThatCausesError)
 	assert.Equal(t, 1, globalCount)
@@ -37,10 +40,9 @@ func TestOverrideGlobalValues(t *testing.T) {
 }
 
 func TestGetLocalOpts(t *testing.T) {
-	oldGlobalOpts := globalOpts
-	t.Cleanup(func() { globalOpts = oldGlobalOpts })
+	t.Parallel()
 
-	globalOpts = &options{
+	globalOpts := &options{
 		errorHandler: func(_ error) {
 			_ = "global error handler"
 		},
@@ -48,7 +50,8 @@ func TestGetLocalOpts(t *testing.T) {
 		tags:       []string{"global tag"},
 	}
 
-	localOpts := getLocalOpts()
+	client := &Client{statsd: &statsd.NoOpClient{}, opts: globalOpts}
+	localOpts := client.getLocalOpts()
 	require.NotSame(t, globalOpts, localOpts, "globalOpts and localOpts are pointing to the same memory")
 
 	assert.Len(t, globalOpts.tags, 1, "globalOpts no longer have 1 tag")
@@ -0,0 +1,19 @@
+package metricstest_test
+
+import (
+	"testing"
+
+	"github.com/coopnorge/go-datadog-lib/v2/metrics"
+	"github.com/coopnorge/go-datadog-lib/v2/metrics/metricstest"
+)
+
+func ExampleStart() {
+	t := &testing.T{} // Use the *testing.T passed to your test instead.
+
+	recorder := metricstest.Start(t)
+
+	// Call the code under test.
+	metrics.Incr("orders.created", metrics.WithTag("channel", "web"))
+
+	recorder.AssertCount(t, "orders.created", 1, "channel:web")
+}
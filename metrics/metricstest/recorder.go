@@ -0,0 +1,351 @@
+// Package metricstest implements an in-memory recorder for asserting on
+// metrics sent with the metrics package in unit tests.
+package metricstest
+
+import (
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/DataDog/datadog-go/v5/statsd"
+	// Imported to ensure that the metrics package has set up the seam.
+	_ "github.com/coopnorge/go-datadog-lib/v2/metrics"
+	"github.com/coopnorge/go-datadog-lib/v2/internal/seam"
+)
+
+// MetricType is the kind of metric that was recorded.
+type MetricType string
+
+// The metric types that can be recorded.
+const (
+	MetricTypeGauge        MetricType = "gauge"
+	MetricTypeCount        MetricType = "count"
+	MetricTypeHistogram    MetricType = "histogram"
+	MetricTypeDistribution MetricType = "distribution"
+	MetricTypeSet          MetricType = "set"
+	MetricTypeTiming       MetricType = "timing"
+)
+
+// Metric is a single recorded call.
+type Metric struct {
+	Name string
+	Type MetricType
+	// Value is the numeric value of the metric. Timings are recorded in
+	// milliseconds. For sets Value is zero, use SetValue instead.
+	Value float64
+	// SetValue is the value of set metrics.
+	SetValue   string
+	Tags       []string
+	SampleRate float64
+	// Timestamp is only set for metrics sent with an explicit timestamp.
+	Timestamp time.Time
+}
+
+// HasTags reports whether the metric has every tag, formatted as "key:value".
+func (m Metric) HasTags(tags ...string) bool {
+	for _, tag := range tags {
+		if !slices.Contains(m.Tags, tag) {
+			return false
+		}
+	}
+	return true
+}
+
+// Tag returns the value of the tag with key, and whether it was found.
+func (m Metric) Tag(key string) (string, bool) {
+	for _, tag := range m.Tags {
+		if k, v, ok := strings.Cut(tag, ":"); ok && k == key {
+			return v, true
+		}
+	}
+	return "", false
+}
+
+// Recorder is a statsd.ClientInterface that stores every call in memory
+// instead of sending it to Dogstatsd.
+type Recorder struct {
+	mu            sync.Mutex
+	metrics       []Metric
+	events        []statsd.Event
+	serviceChecks []statsd.ServiceCheck
+	closed        bool
+}
+
+var _ statsd.ClientInterface = &Recorder{}
+
+// NewRecorder creates an empty Recorder.
+func NewRecorder() *Recorder {
+	return &Recorder{}
+}
+
+// Start replaces the client used by the package-level functions in the
+// metrics package with a new Recorder, and returns the Recorder. The previous
+// client is restored by t.Cleanup.
+//
+// Since the replaced client is global, Start cannot be used in parallel
+// tests.
+func Start(t testing.TB) *Recorder {
+	t.Helper()
+	r := NewRecorder()
+	restore := seam.SwapDefaultStatsdClient(r)
+	t.Cleanup(restore)
+	return r
+}
+
+func (r *Recorder) record(m Metric) error {
+	m.Tags = slices.Clone(m.Tags) // The caller may reuse the slice.
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.metrics = append(r.metrics, m)
+	return nil
+}
+
+// Gauge implements statsd.ClientInterface.
+func (r *Recorder) Gauge(name string, value float64, tags []string, rate float64) error {
+	return r.record(Metric{Name: name, Type: MetricTypeGauge, Value: value, Tags: tags, SampleRate: rate})
+}
+
+// GaugeWithTimestamp implements statsd.ClientInterface.
+func (r *Recorder) GaugeWithTimestamp(name string, value float64, tags []string, rate float64, timestamp time.Time) error {
+	return r.record(Metric{Name: name, Type: MetricTypeGauge, Value: value, Tags: tags, SampleRate: rate, Timestamp: timestamp})
+}
+
+// Count implements statsd.ClientInterface.
+func (r *Recorder) Count(name string, value int64, tags []string, rate float64) error {
+	return r.record(Metric{Name: name, Type: MetricTypeCount, Value: float64(value), Tags: tags, SampleRate: rate})
+}
+
+// CountWithTimestamp implements statsd.ClientInterface.
+func (r *Recorder) CountWithTimestamp(name string, value int64, tags []string, rate float64, timestamp time.Time) error {
+	return r.record(Metric{Name: name, Type: MetricTypeCount, Value: float64(value), Tags: tags, SampleRate: rate, Timestamp: timestamp})
+}
+
+// Histogram implements statsd.ClientInterface.
+func (r *Recorder) Histogram(name string, value float64, tags []string, rate float64) error {
+	return r.record(Metric{Name: name, Type: MetricTypeHistogram, Value: value, Tags: tags, SampleRate: rate})
+}
+
+// Distribution implements statsd.ClientInterface.
+func (r *Recorder) Distribution(name string, value float64, tags []string, rate float64) error {
+	return r.record(Metric{Name: name, Type: MetricTypeDistribution, Value: value, Tags: tags, SampleRate: rate})
+}
+
+// Decr implements statsd.ClientInterface, and is recorded as a Count of -1.
+func (r *Recorder) Decr(name string, tags []string, rate float64) error {
+	return r.Count(name, -1, tags, rate)
+}
+
+// Incr implements statsd.ClientInterface, and is recorded as a Count of 1.
+func (r *Recorder) Incr(name string, tags []string, rate float64) error {
+	return r.Count(name, 1, tags, rate)
+}
+
+// Set implements statsd.ClientInterface.
+func (r *Recorder) Set(name string, value string, tags []string, rate float64) error {
+	return r.record(Metric{Name: name, Type: MetricTypeSet, SetValue: value, Tags: tags, SampleRate: rate})
+}
+
+// Timing implements statsd.ClientInterface, and is recorded in milliseconds.
+func (r *Recorder) Timing(name string, value time.Duration, tags []string, rate float64) error {
+	return r.TimeInMilliseconds(name, value.Seconds()*1000, tags, rate)
+}
+
+// TimeInMilliseconds implements statsd.ClientInterface.
+func (r *Recorder) TimeInMilliseconds(name string, value float64, tags []string, rate float64) error {
+	return r.record(Metric{Name: name, Type: MetricTypeTiming, Value: value, Tags: tags, SampleRate: rate})
+}
+
+// Event implements statsd.ClientInterface.
+func (r *Recorder) Event(e *statsd.Event) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.events = append(r.events, *e)
+	return nil
+}
+
+// SimpleEvent implements statsd.ClientInterface.
+func (r *Recorder) SimpleEvent(title, text string) error {
+	return r.Event(statsd.NewEvent(title, text))
+}
+
+// ServiceCheck implements statsd.ClientInterface.
+func (r *Recorder) ServiceCheck(sc *statsd.ServiceCheck) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.serviceChecks = append(r.serviceChecks, *sc)
+	return nil
+}
+
+// SimpleServiceCheck implements statsd.ClientInterface.
+func (r *Recorder) SimpleServiceCheck(name string, status statsd.ServiceCheckStatus) error {
+	return r.ServiceCheck(statsd.NewServiceCheck(name, status))
+}
+
+// Close implements statsd.ClientInterface. Calls are still recorded after the
+// Recorder is closed.
+func (r *Recorder) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.closed = true
+	return nil
+}
+
+// Flush implements statsd.ClientInterface.
+func (r *Recorder) Flush() error {
+	return nil
+}
+
+// IsClosed implements statsd.ClientInterface.
+func (r *Recorder) IsClosed() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.closed
+}
+
+// GetTelemetry implements statsd.ClientInterface.
+func (r *Recorder) GetTelemetry() statsd.Telemetry {
+	return statsd.Telemetry{}
+}
+
+// Reset discards everything recorded so far.
+func (r *Recorder) Reset() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.metrics = nil
+	r.events = nil
+	r.serviceChecks = nil
+}
+
+// Metrics returns every metric recorded so far.
+func (r *Recorder) Metrics() []Metric {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return slices.Clone(r.metrics)
+}
+
+// Events returns every event recorded so far.
+func (r *Recorder) Events() []statsd.Event {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return slices.Clone(r.events)
+}
+
+// ServiceChecks returns every service check recorded so far.
+func (r *Recorder) ServiceChecks() []statsd.ServiceCheck {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return slices.Clone(r.serviceChecks)
+}
+
+// Find returns every metric recorded so far with the name, that has all of
+// the tags.
+func (r *Recorder) Find(name string, tags ...string) []Metric {
+	var found []Metric
+	for _, m := range r.Metrics() {
+		if m.Name == name && m.HasTags(tags...) {
+			found = append(found, m)
+		}
+	}
+	return found
+}
+
+// Sum returns the sum of the values of every metric recorded with the name,
+// that has all of the tags. For counts, this is the total count.
+func (r *Recorder) Sum(name string, tags ...string) float64 {
+	var sum float64
+	for _, m := range r.Find(name, tags...) {
+		sum += m.Value
+	}
+	return sum
+}
+
+// Last returns the most recently recorded metric with the name, that has all
+// of the tags, and whether one was found. For gauges, this is the current
+// value.
+func (r *Recorder) Last(name string, tags ...string) (Metric, bool) {
+	found := r.Find(name, tags...)
+	if len(found) == 0 {
+		return Metric{}, false
+	}
+	return found[len(found)-1], true
+}
+
+// AssertEmitted asserts that a metric with the name, that has all of the
+// tags, has been recorded.
+func (r *Recorder) AssertEmitted(t testing.TB, name string, tags ...string) bool {
+	t.Helper()
+	if len(r.Find(name, tags...)) == 0 {
+		t.Errorf("expected metric %q with tags %v to be emitted, recorded metrics: %v", name, tags, r.names())
+		return false
+	}
+	return true
+}
+
+// AssertNotEmitted asserts that no metric with the name, that has all of the
+// tags, has been recorded.
+func (r *Recorder) AssertNotEmitted(t testing.TB, name string, tags ...string) bool {
+	t.Helper()
+	if found := r.Find(name, tags...); len(found) > 0 {
+		t.Errorf("expected metric %q with tags %v not to be emitted, but it was emitted %d times", name, tags, len(found))
+		return false
+	}
+	return true
+}
+
+// AssertCount asserts that the sum of every count recorded with the name,
+// that has all of the tags, is want.
+func (r *Recorder) AssertCount(t testing.TB, name string, want int64, tags ...string) bool {
+	t.Helper()
+	var got int64
+	for _, m := range r.Find(name, tags...) {
+		if m.Type == MetricTypeCount {
+			got += int64(m.Value)
+		}
+	}
+	if got != want {
+		t.Errorf("expected count %q with tags %v to be %d, got %d", name, tags, want, got)
+		return false
+	}
+	return true
+}
+
+// AssertGauge asserts that the last gauge recorded with the name, that has
+// all of the tags, has the value want.
+func (r *Recorder) AssertGauge(t testing.TB, name string, want float64, tags ...string) bool {
+	t.Helper()
+	m, ok := r.Last(name, tags...)
+	if !ok || m.Type != MetricTypeGauge {
+		t.Errorf("expected gauge %q with tags %v to be emitted, recorded metrics: %v", name, tags, r.names())
+		return false
+	}
+	if m.Value != want {
+		t.Errorf("expected gauge %q with tags %v to be %v, got %v", name, tags, want, m.Value)
+		return false
+	}
+	return true
+}
+
+// RequireMetric returns the most recently recorded metric with the name, that
+// has all of the tags. The test is stopped if no such metric was recorded.
+func (r *Recorder) RequireMetric(t testing.TB, name string, tags ...string) Metric {
+	t.Helper()
+	m, ok := r.Last(name, tags...)
+	if !ok {
+		t.Fatalf("expected metric %q with tags %v to be emitted, recorded metrics: %v", name, tags, r.names())
+	}
+	return m
+}
+
+// names returns the distinct names of the recorded metrics, for use in
+// failure messages.
+func (r *Recorder) names() []string {
+	var names []string
+	for _, m := range r.Metrics() {
+		if !slices.Contains(names, m.Name) {
+			names = append(names, m.Name)
+		}
+	}
+	return names
+}
@@ -0,0 +1,130 @@
+package metricstest_test
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/DataDog/datadog-go/v5/statsd"
+	"github.com/coopnorge/go-datadog-lib/v2/metrics"
+	"github.com/coopnorge/go-datadog-lib/v2/metrics/metricstest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStart(t *testing.T) {
+	previous := metrics.GlobalClient()
+
+	t.Run("records", func(t *testing.T) {
+		recorder := metricstest.Start(t)
+		assert.Same(t, recorder, metrics.GlobalClient())
+
+		metrics.Gauge("my.gauge", 42, metrics.WithTag("tag1", "value1"))
+		metrics.Count("my.count", 2, metrics.WithTag("tag1", "value1"))
+		metrics.Incr("my.count", metrics.WithTag("tag1", "value2"))
+		metrics.Decr("my.count", metrics.WithTag("tag1", "value2"))
+		metrics.Histogram("my.histogram", 1.5)
+		metrics.Distribution("my.distribution", 2.5, metrics.WithSampleRate(0.5))
+		metrics.Set("my.set", "user-1")
+		metrics.Timing("my.timing", 1500*time.Millisecond)
+		metrics.SimpleEvent("title", "text")
+
+		recorder.AssertGauge(t, "my.gauge", 42, "tag1:value1")
+		recorder.AssertCount(t, "my.count", 2, "tag1:value1")
+		recorder.AssertCount(t, "my.count", 0, "tag1:value2")
+		recorder.AssertCount(t, "my.count", 2)
+		recorder.AssertEmitted(t, "my.histogram")
+		recorder.AssertNotEmitted(t, "my.gauge", "tag1:value2")
+
+		distribution := recorder.RequireMetric(t, "my.distribution")
+		assert.Equal(t, metricstest.MetricTypeDistribution, distribution.Type)
+		assert.Equal(t, 2.5, distribution.Value)
+		assert.Equal(t, 0.5, distribution.SampleRate)
+
+		set := recorder.RequireMetric(t, "my.set")
+		assert.Equal(t, "user-1", set.SetValue)
+
+		timing := recorder.RequireMetric(t, "my.timing")
+		assert.Equal(t, metricstest.MetricTypeTiming, timing.Type)
+		assert.Equal(t, 1500.0, timing.Value)
+
+		require.Len(t, recorder.Events(), 1)
+		assert.Equal(t, "title", recorder.Events()[0].Title)
+
+		recorder.Reset()
+		assert.Empty(t, recorder.Metrics())
+		assert.Empty(t, recorder.Events())
+	})
+
+	assert.Same(t, previous, metrics.GlobalClient(), "the previous client was not restored")
+}
+
+func TestRecorderQueries(t *testing.T) {
+	t.Parallel()
+
+	recorder := metricstest.NewRecorder()
+	require.NoError(t, recorder.Gauge("my.gauge", 1, []string{"a:1", "b:2"}, 1))
+	require.NoError(t, recorder.Gauge("my.gauge", 2, []string{"a:1"}, 1))
+	require.NoError(t, recorder.Gauge("other.gauge", 3, nil, 1))
+	require.NoError(t, recorder.ServiceCheck(statsd.NewServiceCheck("my.check", statsd.Ok)))
+
+	assert.Len(t, recorder.Find("my.gauge"), 2)
+	assert.Len(t, recorder.Find("my.gauge", "a:1", "b:2"), 1)
+	assert.Empty(t, recorder.Find("my.gauge", "c:3"))
+	assert.Equal(t, 3.0, recorder.Sum("my.gauge", "a:1"))
+
+	last, ok := recorder.Last("my.gauge")
+	require.True(t, ok)
+	assert.Equal(t, 2.0, last.Value)
+	value, ok := last.Tag("a")
+	assert.True(t, ok)
+	assert.Equal(t, "1", value)
+	_, ok = last.Tag("b")
+	assert.False(t, ok)
+
+	_, ok = recorder.Last("missing")
+	assert.False(t, ok)
+
+	require.Len(t, recorder.ServiceChecks(), 1)
+	assert.Equal(t, "my.check", recorder.ServiceChecks()[0].Name)
+}
+
+func TestRecorderAssertionsFail(t *testing.T) {
+	t.Parallel()
+
+	recorder := metricstest.NewRecorder()
+	require.NoError(t, recorder.Count("my.count", 1, nil, 1))
+	require.NoError(t, recorder.Gauge("my.gauge", 1, nil, 1))
+
+	tests := []struct {
+		name   string
+		assert func(t testing.TB) bool
+	}{
+		{"AssertEmitted", func(t testing.TB) bool { return recorder.AssertEmitted(t, "missing") }},
+		{"AssertNotEmitted", func(t testing.TB) bool { return recorder.AssertNotEmitted(t, "my.count") }},
+		{"AssertCount", func(t testing.TB) bool { return recorder.AssertCount(t, "my.count", 2) }},
+		{"AssertGauge wrong value", func(t testing.TB) bool { return recorder.AssertGauge(t, "my.gauge", 2) }},
+		{"AssertGauge wrong type", func(t testing.TB) bool { return recorder.AssertGauge(t, "my.count", 1) }},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			ft := &fakeT{TB: t}
+			assert.False(t, tc.assert(ft))
+			assert.Len(t, ft.errors, 1)
+		})
+	}
+}
+
+// fakeT captures errors instead of failing the test.
+type fakeT struct {
+	testing.TB
+	errors []string
+}
+
+func (f *fakeT) Helper() {}
+
+func (f *fakeT) Errorf(format string, args ...any) {
+	f.errors = append(f.errors, fmt.Sprintf(format, args...))
+}
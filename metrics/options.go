@@ -6,7 +6,9 @@ import (
 	"os"
 	"slices"
 	"strings"
+	"time"
 
+	"github.com/DataDog/datadog-go/v5/statsd"
 	ddErrors "github.com/coopnorge/go-datadog-lib/v2/errors"
 	"github.com/coopnorge/go-datadog-lib/v2/internal"
 	"github.com/coopnorge/go-logger"
@@ -24,6 +26,35 @@ type options struct {
 	errorHandler ddErrors.ErrorHandler
 	sampleRate   float64
 	tags         []string
+	timerKind    TimerKind
+
+	// cardinalityLimits is the maximum number of distinct tag combinations
+	// per metric name. defaultCardinalityLimit applies to metrics without a
+	// limit of their own. Zero means unlimited.
+	cardinalityLimits       map[string]int
+	defaultCardinalityLimit int
+
+	gaugePollInterval time.Duration
+
+	// The following options tune the Dogstatsd client. Zero values use the
+	// Dogstatsd client's defaults.
+	namespace                     string
+	clientSideAggregation         bool
+	extendedClientSideAggregation bool
+	aggregationInterval           time.Duration
+	channelMode                   bool
+	channelModeBufferSize         int
+	bufferPoolSize                int
+	maxBytesPerPayload            int
+	maxMessagesPerPayload         int
+
+	// The following options only apply to events and service checks.
+	hostname       string
+	alertType      EventAlertType
+	priority       EventPriority
+	aggregationKey string
+	sourceType     string
+	message        string
 }
 
 func resolveOptions(opts []Option) (*options, error) {
@@ -46,6 +77,7 @@ func resolveOptions(opts []Option) (*options, error) {
 		fmt.Sprintf("version:%s", os.Getenv(internal.DatadogVersion)),
 	}
 
+	opts = append([]Option{withConfigFromEnvVars()}, opts...)
 	if err := options.applyOptions(opts); err != nil {
 		return nil, err
 	}
@@ -53,12 +85,70 @@ func resolveOptions(opts []Option) (*options, error) {
 	return options, nil
 }
 
+// withConfigFromEnvVars tunes the Dogstatsd client with the environment
+// variables. Invalid values are returned as errors.
+func withConfigFromEnvVars() Option {
+	return func(o *options) error {
+		var errs [8]error
+		o.namespace = os.Getenv(internal.DatadogDSDNamespace)
+		o.clientSideAggregation, errs[0] = internal.ParseBool(internal.DatadogDSDClientSideAggregation, o.clientSideAggregation)
+		o.extendedClientSideAggregation, errs[1] = internal.ParseBool(internal.DatadogDSDExtendedClientSideAggregation, o.extendedClientSideAggregation)
+		o.aggregationInterval, errs[2] = internal.ParseDuration(internal.DatadogDSDAggregationInterval, o.aggregationInterval)
+		o.channelMode, errs[3] = internal.ParseBool(internal.DatadogDSDChannelMode, o.channelMode)
+		o.channelModeBufferSize, errs[4] = internal.ParseInt(internal.DatadogDSDChannelModeBufferSize, o.channelModeBufferSize)
+		o.bufferPoolSize, errs[5] = internal.ParseInt(internal.DatadogDSDBufferPoolSize, o.bufferPoolSize)
+		o.maxBytesPerPayload, errs[6] = internal.ParseInt(internal.DatadogDSDMaxBytesPerPayload, o.maxBytesPerPayload)
+		o.maxMessagesPerPayload, errs[7] = internal.ParseInt(internal.DatadogDSDMaxMessagesPerPayload, o.maxMessagesPerPayload)
+		return errors.Join(errs[:]...)
+	}
+}
+
+// statsdOptions returns the options for the Dogstatsd client.
+func (opts *options) statsdOptions() []statsd.Option {
+	statsdOpts := []statsd.Option{statsd.WithTags(opts.tags)}
+	if opts.namespace != "" {
+		statsdOpts = append(statsdOpts, statsd.WithNamespace(opts.namespace))
+	}
+	switch {
+	case opts.extendedClientSideAggregation:
+		statsdOpts = append(statsdOpts, statsd.WithExtendedClientSideAggregation())
+	case opts.clientSideAggregation:
+		statsdOpts = append(statsdOpts, statsd.WithClientSideAggregation())
+	default:
+		statsdOpts = append(statsdOpts, statsd.WithoutClientSideAggregation())
+	}
+	if opts.aggregationInterval > 0 {
+		statsdOpts = append(statsdOpts, statsd.WithAggregationInterval(opts.aggregationInterval))
+	}
+	if opts.channelMode {
+		statsdOpts = append(statsdOpts, statsd.WithChannelMode())
+		if opts.channelModeBufferSize > 0 {
+			statsdOpts = append(statsdOpts, statsd.WithChannelModeBufferSize(opts.channelModeBufferSize))
+		}
+	}
+	if opts.bufferPoolSize > 0 {
+		statsdOpts = append(statsdOpts, statsd.WithBufferPoolSize(opts.bufferPoolSize))
+	}
+	if opts.maxBytesPerPayload > 0 {
+		statsdOpts = append(statsdOpts, statsd.WithMaxBytesPerPayload(opts.maxBytesPerPayload))
+	}
+	if opts.maxMessagesPerPayload > 0 {
+		statsdOpts = append(statsdOpts, statsd.WithMaxMessagesPerPayload(opts.maxMessagesPerPayload))
+	}
+	return statsdOpts
+}
+
 func defaultOptions() *options {
 	return &options{
 		errorHandler: func(err error) {
 			logger.WithError(err).Error(err.Error())
 		},
 		sampleRate: defaultMetricSampleRate,
+		timerKind:  TimerKindDistribution,
+
+		gaugePollInterval: defaultGaugePollInterval,
+
+		clientSideAggregation: true,
 	}
 }
 
@@ -89,20 +179,11 @@ func WithErrorHandler(handler ddErrors.ErrorHandler) Option {
 //   - v: The tag value
 func WithTag(k, v string) Option {
 	return func(options *options) error {
-		if k == "" {
-			return fmt.Errorf("tag key cannot be empty")
+		if err := validateTagKey(k); err != nil {
+			return err
 		}
-		if v == "" {
-			return fmt.Errorf("tag value cannot be empty")
-		}
-		if strings.ContainsAny(k, ":,|=") {
-			return fmt.Errorf("tag key contains invalid characters: %s", k)
-		}
-		if len(k)+len(v)+1 > 200 {
-			return fmt.Errorf("tag %s:%s exceeds maximum length", k, v)
-		}
-		if slices.Contains([]string{"environment", "service", "version"}, strings.ToLower(k)) {
-			return fmt.Errorf("tag key '%s' is reserved", k)
+		if err := validateTagValue(k, v); err != nil {
+			return err
 		}
 
 		options.tags = append(options.tags, fmt.Sprintf("%s:%s", k, v))
@@ -110,6 +191,38 @@ func WithTag(k, v string) Option {
 	}
 }
 
+func validateTagKey(k string) error {
+	if k == "" {
+		return fmt.Errorf("tag key cannot be empty")
+	}
+	if strings.ContainsAny(k, ":,|=") {
+		return fmt.Errorf("tag key contains invalid characters: %s", k)
+	}
+	if slices.Contains([]string{"environment", "service", "version"}, strings.ToLower(k)) {
+		return fmt.Errorf("tag key '%s' is reserved", k)
+	}
+	return nil
+}
+
+func validateTagValue(k, v string) error {
+	if v == "" {
+		return fmt.Errorf("tag value cannot be empty")
+	}
+	if len(k)+len(v)+1 > 200 {
+		return fmt.Errorf("tag %s:%s exceeds maximum length", k, v)
+	}
+	return nil
+}
+
+// withValidatedTags appends tags that are already formatted as "key:value"
+// and validated.
+func withValidatedTags(tags []string) Option {
+	return func(options *options) error {
+		options.tags = append(options.tags, tags...)
+		return nil
+	}
+}
+
 // WithSampleRate sets the sample rate for metrics collection.
 // The sample rate controls what percentage of metrics are actually sent to the backend
 // Parameters:
@@ -133,3 +246,173 @@ func WithSampleRate(rate float64) Option {
 		return nil
 	}
 }
+
+// WithTimerKind sets the kind of metric that durations measured with Timer
+// and Measure are sent as. Defaults to TimerKindDistribution. When passed to
+// GlobalSetup or NewClient, it sets the default for every Timer.
+func WithTimerKind(kind TimerKind) Option {
+	return func(o *options) error {
+		switch kind {
+		case TimerKindDistribution, TimerKindHistogram, TimerKindTiming:
+			o.timerKind = kind
+			return nil
+		default:
+			return fmt.Errorf("unknown timer kind: %d", kind)
+		}
+	}
+}
+
+// WithCardinalityLimit limits the number of distinct tag combinations, or
+// series, sent for the metric name to maxSeries. Once the limit is reached,
+// the value of the tag with the most distinct values is replaced with
+// "other" for any new tag combination, and then the values of the next tags,
+// until the combination is a known series. At most maxSeries combinations
+// with replaced values are kept, after which every tag value is replaced.
+// The overflow is reported to the ErrorHandler and counted in the metric
+// "coopdatadog.metrics.cardinality_overflow".
+//
+// WithCardinalityLimit only has an effect when passed to GlobalSetup or
+// NewClient.
+func WithCardinalityLimit(name string, maxSeries int) Option {
+	return func(o *options) error {
+		if name == "" {
+			return fmt.Errorf("cardinality limit metric name cannot be empty")
+		}
+		if maxSeries <= 0 {
+			return fmt.Errorf("cardinality limit for %s must be positive: %d", name, maxSeries)
+		}
+		if o.cardinalityLimits == nil {
+			o.cardinalityLimits = map[string]int{}
+		}
+		o.cardinalityLimits[name] = maxSeries
+		return nil
+	}
+}
+
+// WithDefaultCardinalityLimit is like WithCardinalityLimit, but applies to
+// every metric that does not have a limit set with WithCardinalityLimit.
+//
+// WithDefaultCardinalityLimit only has an effect when passed to GlobalSetup
+// or NewClient.
+func WithDefaultCardinalityLimit(maxSeries int) Option {
+	return func(o *options) error {
+		if maxSeries <= 0 {
+			return fmt.Errorf("default cardinality limit must be positive: %d", maxSeries)
+		}
+		o.defaultCardinalityLimit = maxSeries
+		return nil
+	}
+}
+
+// WithNamespace sets a prefix that is added to the name of every metric, such
+// as "checkout". A "." is added after the namespace if it does not end with
+// one. Can also be set with the environment variable DD_DOGSTATSD_NAMESPACE.
+// WithNamespace only has an effect when passed to GlobalSetup or NewClient.
+func WithNamespace(namespace string) Option {
+	return func(o *options) error {
+		o.namespace = namespace
+		return nil
+	}
+}
+
+// WithClientSideAggregation sets whether gauges, counts and sets are
+// aggregated in the client before they are sent, which reduces the number of
+// packets sent to the agent. Enabled by default. Can also be set with the
+// environment variable DD_DOGSTATSD_CLIENT_SIDE_AGGREGATION.
+// WithClientSideAggregation only has an effect when passed to GlobalSetup or
+// NewClient.
+func WithClientSideAggregation(enabled bool) Option {
+	return func(o *options) error {
+		o.clientSideAggregation = enabled
+		return nil
+	}
+}
+
+// WithExtendedClientSideAggregation sets whether histograms, distributions and
+// timings are also aggregated in the client. Enabling it also enables
+// client-side aggregation. Disabled by default. Can also be set with the
+// environment variable DD_DOGSTATSD_EXTENDED_CLIENT_SIDE_AGGREGATION.
+// WithExtendedClientSideAggregation only has an effect when passed to
+// GlobalSetup or NewClient.
+func WithExtendedClientSideAggregation(enabled bool) Option {
+	return func(o *options) error {
+		o.extendedClientSideAggregation = enabled
+		return nil
+	}
+}
+
+// WithAggregationInterval sets the interval that aggregated metrics are sent
+// on. Defaults to 2 seconds. Can also be set with the environment variable
+// DD_DOGSTATSD_AGGREGATION_INTERVAL, such as "5s". WithAggregationInterval
+// only has an effect when passed to GlobalSetup or NewClient.
+func WithAggregationInterval(interval time.Duration) Option {
+	return func(o *options) error {
+		if interval <= 0 {
+			return fmt.Errorf("aggregation interval must be positive: %s", interval)
+		}
+		o.aggregationInterval = interval
+		return nil
+	}
+}
+
+// WithChannelMode makes the client queue metrics in a channel with room for
+// bufferSize metrics, instead of locking, which performs better when metrics
+// are sent from many goroutines. Metrics are dropped when the channel is full.
+// A bufferSize of 0 uses the default of 4096. Can also be set with the
+// environment variables DD_DOGSTATSD_CHANNEL_MODE and
+// DD_DOGSTATSD_CHANNEL_MODE_BUFFER_SIZE. WithChannelMode only has an effect
+// when passed to GlobalSetup or NewClient.
+func WithChannelMode(bufferSize int) Option {
+	return func(o *options) error {
+		if bufferSize < 0 {
+			return fmt.Errorf("channel mode buffer size cannot be negative: %d", bufferSize)
+		}
+		o.channelMode = true
+		o.channelModeBufferSize = bufferSize
+		return nil
+	}
+}
+
+// WithBufferPoolSize sets the number of buffers used to serialize metrics.
+// Defaults to 512 for Unix sockets and 2048 for UDP. Can also be set with the
+// environment variable DD_DOGSTATSD_BUFFER_POOL_SIZE. WithBufferPoolSize only
+// has an effect when passed to GlobalSetup or NewClient.
+func WithBufferPoolSize(size int) Option {
+	return func(o *options) error {
+		if size <= 0 {
+			return fmt.Errorf("buffer pool size must be positive: %d", size)
+		}
+		o.bufferPoolSize = size
+		return nil
+	}
+}
+
+// WithMaxBytesPerPayload sets the maximum size of a payload sent to the agent.
+// Defaults to 8192 for Unix sockets and 1432 for UDP, which perform best. Can
+// also be set with the environment variable
+// DD_DOGSTATSD_MAX_BYTES_PER_PAYLOAD. WithMaxBytesPerPayload only has an
+// effect when passed to GlobalSetup or NewClient.
+func WithMaxBytesPerPayload(size int) Option {
+	return func(o *options) error {
+		if size <= 0 {
+			return fmt.Errorf("max bytes per payload must be positive: %d", size)
+		}
+		o.maxBytesPerPayload = size
+		return nil
+	}
+}
+
+// WithMaxMessagesPerPayload sets the maximum number of metrics, events and
+// service checks in a payload sent to the agent. Defaults to no limit other
+// than the size of the payload. Can also be set with the environment variable
+// DD_DOGSTATSD_MAX_MESSAGES_PER_PAYLOAD. WithMaxMessagesPerPayload only has an
+// effect when passed to GlobalSetup or NewClient.
+func WithMaxMessagesPerPayload(n int) Option {
+	return func(o *options) error {
+		if n <= 0 {
+			return fmt.Errorf("max messages per payload must be positive: %d", n)
+		}
+		o.maxMessagesPerPayload = n
+		return nil
+	}
+}
@@ -4,10 +4,13 @@ import (
 	"math"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
 
 	"github.com/stretchr/testify/assert"
+
+	"github.com/coopnorge/go-datadog-lib/v2/internal"
 )
 
 // TestWithTagValidation tests the WithTag.
@@ -123,3 +126,84 @@ func TestMetricOptions(t *testing.T) {
 		})
 	}
 }
+
+// TestStatsdTuningFromEnvVars tests that the Dogstatsd client can be tuned
+// with environment variables, and that options take precedence.
+func TestStatsdTuningFromEnvVars(t *testing.T) {
+	t.Setenv(internal.DatadogDSDEndpoint, "unix:///dev/null")
+	t.Setenv(internal.DatadogEnvironment, "unittest")
+	t.Setenv(internal.DatadogService, "unittest-service")
+	t.Setenv(internal.DatadogVersion, "v0.0.0")
+	t.Setenv(internal.DatadogDSDNamespace, "checkout")
+	t.Setenv(internal.DatadogDSDClientSideAggregation, "false")
+	t.Setenv(internal.DatadogDSDExtendedClientSideAggregation, "true")
+	t.Setenv(internal.DatadogDSDAggregationInterval, "5s")
+	t.Setenv(internal.DatadogDSDChannelMode, "true")
+	t.Setenv(internal.DatadogDSDChannelModeBufferSize, "8192")
+	t.Setenv(internal.DatadogDSDBufferPoolSize, "1024")
+	t.Setenv(internal.DatadogDSDMaxBytesPerPayload, "4096")
+	t.Setenv(internal.DatadogDSDMaxMessagesPerPayload, "512")
+
+	opts, err := resolveOptions([]Option{WithMaxBytesPerPayload(2048)})
+	require.NoError(t, err)
+
+	assert.Equal(t, "checkout", opts.namespace)
+	assert.False(t, opts.clientSideAggregation)
+	assert.True(t, opts.extendedClientSideAggregation)
+	assert.Equal(t, 5*time.Second, opts.aggregationInterval)
+	assert.True(t, opts.channelMode)
+	assert.Equal(t, 8192, opts.channelModeBufferSize)
+	assert.Equal(t, 1024, opts.bufferPoolSize)
+	assert.Equal(t, 2048, opts.maxBytesPerPayload, "the option should take precedence over the environment variable")
+	assert.Equal(t, 512, opts.maxMessagesPerPayload)
+	assert.Len(t, opts.statsdOptions(), 9)
+}
+
+// TestStatsdTuningFromInvalidEnvVars tests that invalid environment variables
+// tuning the Dogstatsd client are reported.
+func TestStatsdTuningFromInvalidEnvVars(t *testing.T) {
+	t.Setenv(internal.DatadogDSDEndpoint, "unix:///dev/null")
+	t.Setenv(internal.DatadogEnvironment, "unittest")
+	t.Setenv(internal.DatadogService, "unittest-service")
+	t.Setenv(internal.DatadogVersion, "v0.0.0")
+	t.Setenv(internal.DatadogDSDChannelMode, "yes please")
+	t.Setenv(internal.DatadogDSDAggregationInterval, "5")
+	t.Setenv(internal.DatadogDSDMaxMessagesPerPayload, "not-a-number")
+
+	_, err := resolveOptions(nil)
+	require.Error(t, err)
+	assert.ErrorContains(t, err, internal.DatadogDSDChannelMode)
+	assert.ErrorContains(t, err, internal.DatadogDSDAggregationInterval)
+	assert.ErrorContains(t, err, internal.DatadogDSDMaxMessagesPerPayload)
+
+	_, err = NewClient()
+	assert.ErrorContains(t, err, internal.DatadogDSDMaxMessagesPerPayload)
+}
+
+// TestStatsdTuningDefaults tests that the Dogstatsd client's defaults are
+// used when nothing is configured.
+func TestStatsdTuningDefaults(t *testing.T) {
+	opts := defaultOptions()
+	assert.True(t, opts.clientSideAggregation)
+	// Only the tags and client-side aggregation are set.
+	assert.Len(t, opts.statsdOptions(), 2)
+}
+
+// TestStatsdTuningValidation tests the validation of the options tuning the
+// Dogstatsd client.
+func TestStatsdTuningValidation(t *testing.T) {
+	t.Parallel()
+
+	for name, opt := range map[string]Option{
+		"Zero aggregation interval":      WithAggregationInterval(0),
+		"Negative channel buffer size":   WithChannelMode(-1),
+		"Zero buffer pool size":          WithBufferPoolSize(0),
+		"Negative max bytes per payload": WithMaxBytesPerPayload(-1),
+		"Zero max messages per payload":  WithMaxMessagesPerPayload(0),
+	} {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			assert.Error(t, opt(defaultOptions()))
+		})
+	}
+}
@@ -0,0 +1,162 @@
+package metrics
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// TimerKind is the kind of metric a Timer sends durations as.
+type TimerKind int
+
+const (
+	// TimerKindDistribution sends durations as a Distribution. This is the
+	// default, as it allows for percentiles across every host.
+	TimerKindDistribution TimerKind = iota
+	// TimerKindHistogram sends durations as a Histogram.
+	TimerKindHistogram
+	// TimerKindTiming sends durations as a Timing.
+	TimerKindTiming
+)
+
+const (
+	outcomeTagKey     = "outcome"
+	outcomeTagSuccess = "success"
+	outcomeTagError   = "error"
+)
+
+// Timer measures the duration of an operation, and sends it in milliseconds.
+// Create a Timer with StartTimer.
+type Timer struct {
+	client  *Client
+	ctx     context.Context
+	name    string
+	options []Option
+	start   time.Time
+
+	stopOnce sync.Once
+	elapsed  time.Duration
+}
+
+// StartTimer starts a Timer, that will send the duration to the metric name
+// when stopped. The options are applied when the duration is sent.
+func StartTimer(name string, options ...Option) *Timer {
+	return DefaultClient().StartTimer(name, options...)
+}
+
+// StartTimerCtx is like StartTimer, but also adds the tags carried by ctx. See ContextWithTags.
+func StartTimerCtx(ctx context.Context, name string, options ...Option) *Timer {
+	return DefaultClient().StartTimerCtx(ctx, name, options...)
+}
+
+// Measure calls fn and sends its duration to the metric name, tagged with
+// "outcome:success" or "outcome:error" depending on the error returned by fn.
+// An "outcome" tag given in the options or carried by the context is replaced.
+// The error returned by fn is returned.
+func Measure(name string, fn func() error, options ...Option) error {
+	return DefaultClient().Measure(name, fn, options...)
+}
+
+// MeasureCtx is like Measure, but also adds the tags carried by ctx. See ContextWithTags.
+func MeasureCtx(ctx context.Context, name string, fn func() error, options ...Option) error {
+	return DefaultClient().MeasureCtx(ctx, name, fn, options...)
+}
+
+// StartTimer starts a Timer, that will send the duration to the metric name
+// when stopped. The options are applied when the duration is sent.
+func (c *Client) StartTimer(name string, options ...Option) *Timer {
+	return c.StartTimerCtx(context.Background(), name, options...)
+}
+
+// StartTimerCtx is like StartTimer, but also adds the tags carried by ctx. See ContextWithTags.
+func (c *Client) StartTimerCtx(ctx context.Context, name string, options ...Option) *Timer {
+	return &Timer{
+		client:  c,
+		ctx:     ctx,
+		name:    name,
+		options: options,
+		start:   time.Now(),
+	}
+}
+
+// Measure calls fn and sends its duration to the metric name, tagged with
+// "outcome:success" or "outcome:error" depending on the error returned by fn.
+// An "outcome" tag given in the options or carried by the context is replaced.
+// The error returned by fn is returned.
+func (c *Client) Measure(name string, fn func() error, options ...Option) error {
+	return c.MeasureCtx(context.Background(), name, fn, options...)
+}
+
+// MeasureCtx is like Measure, but also adds the tags carried by ctx. See ContextWithTags.
+func (c *Client) MeasureCtx(ctx context.Context, name string, fn func() error, options ...Option) error {
+	timer := c.StartTimerCtx(ctx, name, options...)
+	err := fn()
+	outcome := outcomeTagSuccess
+	if err != nil {
+		outcome = outcomeTagError
+	}
+	timer.Stop(withOutcomeTag(outcome))
+	return err
+}
+
+// withOutcomeTag tags the duration with the outcome, replacing any outcome tag
+// set by the options. Tags carried by the context are only added for keys
+// that are not set, so it also replaces an outcome tag of the context.
+func withOutcomeTag(outcome string) Option {
+	return func(options *options) error {
+		options.tags = slices.DeleteFunc(options.tags, func(tag string) bool {
+			key, _, _ := strings.Cut(tag, ":")
+			return key == outcomeTagKey
+		})
+		return WithTag(outcomeTagKey, outcome)(options)
+	}
+}
+
+// Stop sends the duration since the Timer was started, and returns it. The
+// options are applied in addition to the options given to StartTimer. Only the
+// first call to Stop sends the duration, later calls return the same duration
+// without sending anything.
+func (t *Timer) Stop(options ...Option) time.Duration {
+	t.stopOnce.Do(func() {
+		t.elapsed = t.ObserveDuration(options...)
+	})
+	return t.elapsed
+}
+
+// ObserveDuration sends the duration since the Timer was started, and returns
+// it. Unlike Stop, every call to ObserveDuration sends the duration, which
+// can be used to measure the time until several steps of an operation are
+// done.
+func (t *Timer) ObserveDuration(options ...Option) time.Duration {
+	elapsed := time.Since(t.start)
+	allOptions := make([]Option, 0, len(t.options)+len(options))
+	allOptions = append(allOptions, t.options...)
+	allOptions = append(allOptions, options...)
+	t.client.sendDuration(t.ctx, t.name, elapsed, allOptions)
+	return elapsed
+}
+
+// sendDuration sends the duration in milliseconds, as the kind of metric
+// configured with WithTimerKind.
+func (c *Client) sendDuration(ctx context.Context, name string, d time.Duration, options []Option) {
+	localOpts, err := c.resolveLocalOpts(ctx, name, options)
+	if err != nil {
+		localOpts.errorHandler(fmt.Errorf("failed to apply metric options: %w", err))
+		return
+	}
+	ms := d.Seconds() * 1000
+	switch localOpts.timerKind {
+	case TimerKindHistogram:
+		err = c.statsd.Histogram(name, ms, localOpts.tags, localOpts.sampleRate)
+	case TimerKindTiming:
+		err = c.statsd.TimeInMilliseconds(name, ms, localOpts.tags, localOpts.sampleRate)
+	default:
+		err = c.statsd.Distribution(name, ms, localOpts.tags, localOpts.sampleRate)
+	}
+	if err != nil {
+		localOpts.errorHandler(fmt.Errorf("failed to send duration: %w", err))
+	}
+}
@@ -0,0 +1,94 @@
+package metrics_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/coopnorge/go-datadog-lib/v2/metrics"
+	"github.com/coopnorge/go-datadog-lib/v2/metrics/metricstest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTimer(t *testing.T) {
+	recorder := metricstest.Start(t)
+
+	timer := metrics.StartTimer("my.timer", metrics.WithTag("tag1", "value1"))
+	time.Sleep(10 * time.Millisecond)
+	elapsed := timer.Stop(metrics.WithTag("tag2", "value2"))
+	assert.GreaterOrEqual(t, elapsed, 10*time.Millisecond)
+	assert.Equal(t, elapsed, timer.Stop(), "a second Stop returned a different duration")
+
+	found := recorder.Find("my.timer")
+	require.Len(t, found, 1, "a second Stop sent the duration again")
+	assert.Equal(t, metricstest.MetricTypeDistribution, found[0].Type)
+	assert.InDelta(t, elapsed.Seconds()*1000, found[0].Value, 0.001)
+	assert.ElementsMatch(t, []string{"tag1:value1", "tag2:value2"}, found[0].Tags)
+}
+
+func TestTimerObserveDuration(t *testing.T) {
+	recorder := metricstest.Start(t)
+
+	ctx := metrics.ContextWithTags(context.Background(), "tenant", "coop")
+	timer := metrics.StartTimerCtx(ctx, "my.timer")
+	timer.ObserveDuration(metrics.WithTag("step", "first"))
+	timer.ObserveDuration(metrics.WithTag("step", "second"))
+
+	recorder.AssertEmitted(t, "my.timer", "tenant:coop", "step:first")
+	recorder.AssertEmitted(t, "my.timer", "tenant:coop", "step:second")
+}
+
+func TestTimerKind(t *testing.T) {
+	recorder := metricstest.Start(t)
+
+	tests := []struct {
+		kind metrics.TimerKind
+		want metricstest.MetricType
+	}{
+		{metrics.TimerKindDistribution, metricstest.MetricTypeDistribution},
+		{metrics.TimerKindHistogram, metricstest.MetricTypeHistogram},
+		{metrics.TimerKindTiming, metricstest.MetricTypeTiming},
+	}
+	for _, tc := range tests {
+		recorder.Reset()
+		metrics.StartTimer("my.timer", metrics.WithTimerKind(tc.kind)).Stop()
+		assert.Equal(t, tc.want, recorder.RequireMetric(t, "my.timer").Type)
+	}
+
+	var errs []error
+	metrics.StartTimer("invalid.timer", metrics.WithTimerKind(metrics.TimerKind(42)), metrics.WithErrorHandler(func(err error) { errs = append(errs, err) })).Stop()
+	assert.Len(t, errs, 1)
+	recorder.AssertNotEmitted(t, "invalid.timer")
+}
+
+func TestMeasure(t *testing.T) {
+	recorder := metricstest.Start(t)
+
+	err := metrics.Measure("my.operation", func() error { return nil }, metrics.WithTag("tag1", "value1"))
+	require.NoError(t, err)
+
+	wantErr := errors.New("failed")
+	err = metrics.MeasureCtx(metrics.ContextWithTags(context.Background(), "tenant", "coop"), "my.operation", func() error { return wantErr })
+	require.ErrorIs(t, err, wantErr)
+
+	recorder.AssertEmitted(t, "my.operation", "outcome:success", "tag1:value1")
+	recorder.AssertEmitted(t, "my.operation", "outcome:error", "tenant:coop")
+}
+
+func TestMeasureReplacesOutcomeTag(t *testing.T) {
+	recorder := metricstest.Start(t)
+
+	err := metrics.Measure("my.operation", func() error { return nil }, metrics.WithTag("outcome", "error"))
+	require.NoError(t, err)
+
+	ctx := metrics.ContextWithTags(context.Background(), "outcome", "success")
+	err = metrics.MeasureCtx(ctx, "my.operation", func() error { return errors.New("failed") })
+	require.Error(t, err)
+
+	found := recorder.Find("my.operation")
+	require.Len(t, found, 2)
+	assert.Equal(t, []string{"outcome:success"}, found[0].Tags)
+	assert.Equal(t, []string{"outcome:error"}, found[1].Tags)
+}
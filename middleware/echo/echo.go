@@ -10,13 +10,16 @@ import (
 // convenience function that calls [ddEcho.Wrap] with the provided [echo.Echo]
 // It is recommended to use this if you want to benefit from future tracer
 // features that require additional properties to be configured without having
-// to update your code.
-func Wrap(e *echo.Echo) *echo.Echo {
+// to update your code. The options configure the spans of the requests.
+
+func Wrap(e *echo.Echo, options ...Option) *echo.Echo {
 	if internal.IsDatadogDisabled() {
 		return e
 	}
 
-	e = ddEcho.Wrap(e)
+	cfg := newConfig(options...)
+	e = ddEcho.Wrap(e, convertOptions(cfg)...)
+	e.Use(cfg.spanMiddleware())
 	return e
 }
 
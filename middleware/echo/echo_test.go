@@ -2,8 +2,13 @@ package echo_test
 
 import (
 	"net/http"
+	"net/http/httptest"
 	"testing"
 
+	"github.com/DataDog/dd-trace-go/v2/ddtrace/ext"
+	"github.com/DataDog/dd-trace-go/v2/ddtrace/mocktracer"
+
+	"github.com/coopnorge/go-datadog-lib/v2/internal"
 	mock_echo "github.com/coopnorge/go-datadog-lib/v2/internal/generated/mocks/labstack/echo/v4"
 	"github.com/coopnorge/go-datadog-lib/v2/internal/testhelpers"
 
@@ -40,3 +45,93 @@ func TestTraceServerMiddleware(t *testing.T) {
 
 	assert.Nil(t, echoHandlerFunc)
 }
+
+func TestWrapWithOptions(t *testing.T) {
+	testhelpers.ConfigureDatadog(t)
+	testTracer := mocktracer.Start()
+	t.Cleanup(testTracer.Stop)
+
+	e := coopEchoDatadog.Wrap(echo.New(),
+		coopEchoDatadog.WithServiceName("my-service"),
+		coopEchoDatadog.WithCustomTag("team", "checkout"),
+		coopEchoDatadog.WithRequestIgnorer(func(c echo.Context) bool { return c.Path() == "/healthz" }),
+		coopEchoDatadog.WithErrorStatuses(func(status int) bool { return status >= 400 }),
+		coopEchoDatadog.WithRequestHeaderTags("X-Tenant", "Authorization"),
+		coopEchoDatadog.WithResponseHeaderTags("X-Cache"),
+	)
+	e.GET("/healthz", func(c echo.Context) error {
+		return c.NoContent(http.StatusOK)
+	})
+	e.GET("/orders/:id", func(c echo.Context) error {
+		c.Response().Header().Set("X-Cache", "miss")
+		return echo.NewHTTPError(http.StatusNotFound)
+	})
+
+	e.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/healthz", nil))
+	assert.Empty(t, testTracer.FinishedSpans())
+
+	req := httptest.NewRequest(http.MethodGet, "/orders/12345", nil)
+	req.Header.Set("X-Tenant", "coop-norge")
+	e.ServeHTTP(httptest.NewRecorder(), req)
+
+	spans := testTracer.FinishedSpans()
+	require.Len(t, spans, 1)
+	span := spans[0]
+	assert.Equal(t, "my-service", span.Tag(ext.ServiceName))
+	assert.Equal(t, "checkout", span.Tag("team"))
+	assert.Equal(t, "GET /orders/:id", span.Tag(ext.ResourceName))
+	assert.Equal(t, "coop-norge", span.Tag("http.request.headers.x-tenant"))
+	assert.Nil(t, span.Tag("http.request.headers.authorization"))
+	assert.Equal(t, "miss", span.Tag("http.response.headers.x-cache"))
+	assert.NotNil(t, span.Tag(ext.ErrorMsg))
+}
+
+func TestWrapWithHashedTagValues(t *testing.T) {
+	testhelpers.ConfigureDatadog(t)
+	testTracer := mocktracer.Start()
+	t.Cleanup(testTracer.Stop)
+
+	e := coopEchoDatadog.Wrap(echo.New(),
+		coopEchoDatadog.WithRequestHeaderTags("X-Tenant", "X-User-Id"),
+		coopEchoDatadog.WithResponseHeaderTags("X-Session-Id"),
+		coopEchoDatadog.WithHashedTagValues("x-user-id", "X-Session-Id"),
+	)
+	e.GET("/orders", func(c echo.Context) error {
+		c.Response().Header().Set("X-Session-Id", "session-7")
+		return c.NoContent(http.StatusOK)
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/orders", nil)
+	req.Header.Set("X-Tenant", "coop-norge")
+	req.Header.Set("X-User-Id", "user-42")
+	e.ServeHTTP(httptest.NewRecorder(), req)
+
+	spans := testTracer.FinishedSpans()
+	require.Len(t, spans, 1)
+	span := spans[0]
+	assert.Equal(t, "coop-norge", span.Tag("http.request.headers.x-tenant"))
+	assert.Equal(t, internal.HashTagValue("user-42"), span.Tag("http.request.headers.x-user-id"))
+	assert.Equal(t, internal.HashTagValue("session-7"), span.Tag("http.response.headers.x-session-id"))
+	for tag, tagValue := range span.Tags() {
+		if str, ok := tagValue.(string); ok {
+			assert.NotContains(t, str, "user-42", "Tag %q contains a hashed value", tag)
+			assert.NotContains(t, str, "session-7", "Tag %q contains a hashed value", tag)
+		}
+	}
+}
+
+func TestWrapWithResourceNamer(t *testing.T) {
+	testhelpers.ConfigureDatadog(t)
+	testTracer := mocktracer.Start()
+	t.Cleanup(testTracer.Stop)
+
+	e := coopEchoDatadog.Wrap(echo.New(), coopEchoDatadog.WithResourceNamer(coopEchoDatadog.StaticResourceNamer("orders")))
+	e.GET("/orders/:id", func(c echo.Context) error {
+		return c.NoContent(http.StatusOK)
+	})
+	e.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/orders/12345", nil))
+
+	spans := testTracer.FinishedSpans()
+	require.Len(t, spans, 1)
+	assert.Equal(t, "orders", spans[0].Tag(ext.ResourceName))
+}
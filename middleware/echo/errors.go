@@ -0,0 +1,54 @@
+package echo
+
+import (
+	"errors"
+
+	"github.com/DataDog/dd-trace-go/v2/ddtrace/ext"
+	"github.com/DataDog/dd-trace-go/v2/ddtrace/tracer"
+	"github.com/coopnorge/go-datadog-lib/v2/internal"
+	"github.com/labstack/echo/v4"
+)
+
+// errorRecordedKey is the key of the echo.Context value that is set when the
+// error of the request has been recorded on the span by ErrorHandler.
+const errorRecordedKey = "datadog.error_recorded"
+
+// ErrorHandler wraps the [echo.HTTPErrorHandler] of the application, so that
+// errors returned by the handlers are recorded on the span of the request,
+// with the type, message and stack trace of the error. The internal error of
+// an [echo.HTTPError] is recorded instead of the HTTPError itself. Only
+// errors whose final status is an error, which by default is a 5xx status,
+// are recorded, see WithErrorStatuses.
+//
+// Echo calls the error handler after the span of [Wrap] has finished, unless
+// WithErrorHandling is passed to [Wrap], so ErrorHandler should be combined
+// with it.
+//
+//	e := coopEchoDatadog.Wrap(echo.New(), coopEchoDatadog.WithErrorHandling())
+//	e.HTTPErrorHandler = coopEchoDatadog.ErrorHandler(e.DefaultHTTPErrorHandler)
+func ErrorHandler(handler echo.HTTPErrorHandler, options ...Option) echo.HTTPErrorHandler {
+	if internal.IsDatadogDisabled() {
+		return handler
+	}
+	cfg := newConfig(options...)
+	return func(err error, c echo.Context) {
+		handler(err, c)
+		span, ok := tracer.SpanFromContext(c.Request().Context())
+		if !ok || c.Get(errorRecordedKey) != nil || !cfg.isError(responseStatus(c, err)) {
+			return
+		}
+		recordError(c, span, err)
+	}
+}
+
+// recordError records err on the span, with its type, message and stack trace.
+// The internal error of an [echo.HTTPError] is recorded instead of the
+// HTTPError itself.
+func recordError(c echo.Context, span *tracer.Span, err error) {
+	var httpErr *echo.HTTPError
+	if errors.As(err, &httpErr) && httpErr.Internal != nil {
+		err = httpErr.Internal
+	}
+	span.SetTag(ext.Error, err)
+	c.Set(errorRecordedKey, true)
+}
@@ -0,0 +1,142 @@
+package echo_test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/DataDog/dd-trace-go/v2/ddtrace/ext"
+	"github.com/DataDog/dd-trace-go/v2/ddtrace/mocktracer"
+	"github.com/coopnorge/go-datadog-lib/v2/internal/testhelpers"
+	coopEchoDatadog "github.com/coopnorge/go-datadog-lib/v2/middleware/echo"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var errOrderNotFound = errors.New("order not found")
+
+type testError struct{}
+
+func (testError) Error() string { return "database unavailable" }
+
+func TestErrorHandler(t *testing.T) {
+	testhelpers.ConfigureDatadog(t)
+	testTracer := mocktracer.Start()
+	t.Cleanup(testTracer.Stop)
+
+	e := coopEchoDatadog.Wrap(echo.New(), coopEchoDatadog.WithErrorHandling())
+	e.HTTPErrorHandler = coopEchoDatadog.ErrorHandler(func(err error, c echo.Context) {
+		// The application maps its own errors to statuses.
+		if errors.Is(err, errOrderNotFound) {
+			_ = c.NoContent(http.StatusNotFound)
+			return
+		}
+		_ = c.NoContent(http.StatusServiceUnavailable)
+	})
+	e.GET("/orders/:id", func(c echo.Context) error {
+		if c.Param("id") == "missing" {
+			return errOrderNotFound
+		}
+		return &echo.HTTPError{Code: http.StatusOK, Internal: testError{}}
+	})
+
+	tests := []struct {
+		path    string
+		status  int
+		errType any
+		errMsg  any
+	}{
+		{"/orders/missing", http.StatusNotFound, nil, nil},
+		{"/orders/12345", http.StatusServiceUnavailable, "echo_test.testError", "database unavailable"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.path, func(t *testing.T) {
+			testTracer.Reset()
+			rec := httptest.NewRecorder()
+			e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tc.path, nil))
+			assert.Equal(t, tc.status, rec.Code)
+
+			spans := testTracer.FinishedSpans()
+			require.Len(t, spans, 1)
+			assert.Equal(t, strconv.Itoa(tc.status), spans[0].Tag(ext.HTTPCode))
+			assert.Equal(t, tc.errType, spans[0].Tag(ext.ErrorType))
+			assert.Equal(t, tc.errMsg, spans[0].Tag(ext.ErrorMsg))
+		})
+	}
+}
+
+func TestWrapWithErrorHandlerStatus(t *testing.T) {
+	testhelpers.ConfigureDatadog(t)
+	testTracer := mocktracer.Start()
+	t.Cleanup(testTracer.Stop)
+
+	e := coopEchoDatadog.Wrap(echo.New(), coopEchoDatadog.WithErrorHandling())
+	e.HTTPErrorHandler = func(_ error, c echo.Context) {
+		_ = c.NoContent(http.StatusInternalServerError)
+	}
+	e.GET("/orders", func(echo.Context) error {
+		return echo.NewHTTPError(http.StatusOK)
+	})
+
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/orders", nil))
+	assert.Equal(t, http.StatusInternalServerError, rec.Code)
+
+	spans := testTracer.FinishedSpans()
+	require.Len(t, spans, 1)
+	assert.Equal(t, "500", spans[0].Tag(ext.HTTPCode))
+	assert.Equal(t, "code=200, message=OK", spans[0].Tag(ext.ErrorMsg), "the returned error should be recorded")
+}
+
+func TestWrapRecordsHandlerError(t *testing.T) {
+	testhelpers.ConfigureDatadog(t)
+	testTracer := mocktracer.Start()
+	t.Cleanup(testTracer.Stop)
+
+	tests := []struct {
+		name         string
+		options      []coopEchoDatadog.Option
+		path         string
+		status       int
+		errMsg       string
+		outerSeesErr bool
+	}{
+		{"Default", nil, "/orders", http.StatusInternalServerError, "db connection refused", true},
+		{"Error handling", []coopEchoDatadog.Option{coopEchoDatadog.WithErrorHandling()}, "/orders", http.StatusInternalServerError, "db connection refused", false},
+		{"Error handling internal error", []coopEchoDatadog.Option{coopEchoDatadog.WithErrorHandling()}, "/users", http.StatusBadGateway, "database unavailable", false},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			testTracer.Reset()
+			var outerErr error
+			e := echo.New()
+			// A middleware registered before Wrap, such as a logger.
+			e.Use(func(next echo.HandlerFunc) echo.HandlerFunc {
+				return func(c echo.Context) error {
+					outerErr = next(c)
+					return outerErr
+				}
+			})
+			e = coopEchoDatadog.Wrap(e, tc.options...)
+			e.GET("/orders", func(echo.Context) error {
+				return errors.New("db connection refused")
+			})
+			e.GET("/users", func(echo.Context) error {
+				return echo.NewHTTPError(http.StatusBadGateway).SetInternal(testError{})
+			})
+
+			rec := httptest.NewRecorder()
+			e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tc.path, nil))
+			assert.Equal(t, tc.status, rec.Code)
+			assert.Equal(t, tc.outerSeesErr, outerErr != nil)
+
+			spans := testTracer.FinishedSpans()
+			require.Len(t, spans, 1)
+			assert.Equal(t, strconv.Itoa(tc.status), spans[0].Tag(ext.HTTPCode))
+			assert.Equal(t, tc.errMsg, spans[0].Tag(ext.ErrorMsg))
+		})
+	}
+}
@@ -0,0 +1,106 @@
+package echo
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/coopnorge/go-datadog-lib/v2/internal"
+	"github.com/coopnorge/go-datadog-lib/v2/metrics"
+	"github.com/labstack/echo/v4"
+)
+
+// Names of the metrics sent by MetricsMiddleware.
+const (
+	MetricServerRequests = "http.server.requests"
+	MetricServerErrors   = "http.server.errors"
+	MetricServerDuration = "http.server.duration"
+	MetricServerInFlight = "http.server.in_flight"
+)
+
+// MetricsMiddleware returns a middleware that sends metrics for every
+// request: the number of requests, the number of failed requests, the
+// duration of each request in milliseconds, as a distribution, and the number
+// of requests in flight, as a gauge. The metrics are tagged with the route,
+// such as "/orders/:id", the method and the status class, such as "2xx",
+// except the gauge, which is not tagged with the status class. Responses with
+// a 5xx status are counted as failed, unless WithErrorStatuses is used, and
+// requests matched by WithRequestIgnorer are skipped.
+//
+// The metrics do not depend on the spans created by Wrap, so they are sent
+// whether or not the request is traced or sampled. Register it after Wrap, so
+// that the metrics are sent with the trace of the request.
+func MetricsMiddleware(options ...Option) echo.MiddlewareFunc {
+	if internal.IsDatadogDisabled() {
+		return noOpMiddlewareFunc()
+	}
+	cfg := newConfig(options...)
+	var inFlight sync.Map // The number of requests in flight per method and route, as *atomic.Int64.
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			if cfg.requestIgnorer != nil && cfg.requestIgnorer(c) {
+				return next(c)
+			}
+			req := c.Request()
+			ctx := req.Context()
+			route := c.Path()
+			if route == "" {
+				route = "unknown"
+			}
+			opts := []metrics.Option{
+				metrics.WithTag("route", route),
+				metrics.WithTag("method", req.Method),
+			}
+
+			counter, _ := inFlight.LoadOrStore(req.Method+" "+route, new(atomic.Int64))
+			inFlightRequests := counter.(*atomic.Int64)
+			metrics.GaugeCtx(ctx, MetricServerInFlight, float64(inFlightRequests.Add(1)), opts...)
+
+			start := time.Now()
+			err := next(c)
+			elapsed := time.Since(start)
+
+			metrics.GaugeCtx(ctx, MetricServerInFlight, float64(inFlightRequests.Add(-1)), opts...)
+
+			status := responseStatus(c, err)
+			opts = append(opts, metrics.WithTag("status_class", strconv.Itoa(status/100)+"xx"))
+			metrics.IncrCtx(ctx, MetricServerRequests, opts...)
+			if cfg.isError(status) {
+				metrics.IncrCtx(ctx, MetricServerErrors, opts...)
+			}
+			metrics.DistributionCtx(ctx, MetricServerDuration, float64(elapsed)/float64(time.Millisecond), opts...)
+			return err
+		}
+	}
+}
+
+// isError reports whether the response status should be counted as an error.
+// Unless WithErrorStatuses is used, 5xx responses are counted as errors.
+func (cfg *config) isError(status int) bool {
+	if cfg.isErrorStatus != nil {
+		return cfg.isErrorStatus(status)
+	}
+	return status >= http.StatusInternalServerError
+}
+
+// responseStatus returns the status of the response to the request, once the
+// handler has returned err. Errors are written by the error handler of Echo
+// after the middlewares have returned, so the status is taken from the error
+// unless the response has already been written.
+func responseStatus(c echo.Context, err error) int {
+	res := c.Response()
+	if err == nil || res.Committed {
+		if res.Status == 0 {
+			return http.StatusOK
+		}
+		return res.Status
+	}
+	var httpErr *echo.HTTPError
+	if errors.As(err, &httpErr) {
+		return httpErr.Code
+	}
+	return http.StatusInternalServerError
+}
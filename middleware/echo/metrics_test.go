@@ -0,0 +1,68 @@
+package echo_test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/coopnorge/go-datadog-lib/v2/internal/testhelpers"
+	"github.com/coopnorge/go-datadog-lib/v2/metrics/metricstest"
+	coopEchoDatadog "github.com/coopnorge/go-datadog-lib/v2/middleware/echo"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMetricsMiddleware(t *testing.T) {
+	testhelpers.ConfigureDatadog(t)
+	recorder := metricstest.Start(t)
+
+	e := echo.New()
+	e.Use(coopEchoDatadog.MetricsMiddleware(
+		coopEchoDatadog.WithRequestIgnorer(func(c echo.Context) bool { return c.Path() == "/healthz" }),
+	))
+	e.GET("/healthz", func(c echo.Context) error {
+		return c.NoContent(http.StatusOK)
+	})
+	e.GET("/orders/:id", func(c echo.Context) error {
+		switch c.Param("id") {
+		case "missing":
+			return echo.NewHTTPError(http.StatusNotFound)
+		case "broken":
+			return errors.New("broken")
+		}
+		return c.NoContent(http.StatusOK)
+	})
+
+	for _, path := range []string{"/orders/1", "/orders/2", "/orders/missing", "/orders/broken", "/healthz"} {
+		e.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+
+	recorder.AssertCount(t, coopEchoDatadog.MetricServerRequests, 2, "route:/orders/:id", "method:GET", "status_class:2xx")
+	recorder.AssertCount(t, coopEchoDatadog.MetricServerRequests, 1, "status_class:4xx")
+	recorder.AssertCount(t, coopEchoDatadog.MetricServerRequests, 1, "status_class:5xx")
+	recorder.AssertCount(t, coopEchoDatadog.MetricServerRequests, 4)
+	recorder.AssertCount(t, coopEchoDatadog.MetricServerErrors, 1, "status_class:5xx")
+	recorder.AssertCount(t, coopEchoDatadog.MetricServerErrors, 1)
+	assert.Len(t, recorder.Find(coopEchoDatadog.MetricServerDuration), 4)
+	assert.Len(t, recorder.Find(coopEchoDatadog.MetricServerInFlight), 8)
+	recorder.AssertGauge(t, coopEchoDatadog.MetricServerInFlight, 0, "route:/orders/:id", "method:GET")
+	recorder.AssertNotEmitted(t, coopEchoDatadog.MetricServerRequests, "route:/healthz")
+}
+
+func TestMetricsMiddlewareWithErrorStatuses(t *testing.T) {
+	testhelpers.ConfigureDatadog(t)
+	recorder := metricstest.Start(t)
+
+	e := echo.New()
+	e.Use(coopEchoDatadog.MetricsMiddleware(
+		coopEchoDatadog.WithErrorStatuses(func(status int) bool { return status == http.StatusTooManyRequests }),
+	))
+	e.GET("/orders", func(echo.Context) error {
+		return echo.NewHTTPError(http.StatusTooManyRequests)
+	})
+
+	e.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/orders", nil))
+
+	recorder.AssertCount(t, coopEchoDatadog.MetricServerErrors, 1, "status_class:4xx")
+}
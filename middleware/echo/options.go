@@ -0,0 +1,249 @@
+package echo
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+
+	ddEcho "github.com/DataDog/dd-trace-go/contrib/labstack/echo.v4/v2"
+	"github.com/DataDog/dd-trace-go/v2/ddtrace/ext"
+	"github.com/DataDog/dd-trace-go/v2/ddtrace/tracer"
+	"github.com/coopnorge/go-datadog-lib/v2/internal"
+	"github.com/labstack/echo/v4"
+)
+
+// Prefixes of the tags attached by WithRequestHeaderTags and
+// WithResponseHeaderTags.
+const (
+	requestHeaderTagPrefix  = "http.request.headers."
+	responseHeaderTagPrefix = "http.response.headers."
+)
+
+// ResourceNamer is a function that will be called to determine what the
+// ResourceName of the span should be.
+type ResourceNamer func(c echo.Context) string
+
+// RequestIgnorer is a function that will be called to determine if the request
+// should be traced or not.
+type RequestIgnorer func(c echo.Context) bool
+
+type config struct {
+	serviceName     string
+	resourceNamer   ResourceNamer
+	requestIgnorer  RequestIgnorer
+	tags            map[string]any
+	isErrorStatus   func(status int) bool
+	requestHeaders  []string
+	responseHeaders []string
+	hashedValues    map[string]struct{}
+	handleErrors    bool
+}
+
+func defaults() *config {
+	serviceName := os.Getenv("DD_SERVICE")
+	return &config{
+		serviceName:    serviceName,
+		resourceNamer:  RouteResourceNamer(),
+		requestIgnorer: nil,
+		tags:           nil,
+	}
+}
+
+func newConfig(options ...Option) *config {
+	cfg := defaults()
+	for _, opt := range options {
+		opt(cfg)
+	}
+	return cfg
+}
+
+// convertOptions converts the config to ddEcho-typed options.
+func convertOptions(cfg *config) []ddEcho.Option {
+	opts := make([]ddEcho.Option, 0, 3+len(cfg.tags))
+	if cfg.serviceName != "" {
+		opts = append(opts, ddEcho.WithService(cfg.serviceName))
+	}
+	if cfg.requestIgnorer != nil {
+		opts = append(opts, ddEcho.WithIgnoreRequest(ddEcho.IgnoreRequestFunc(cfg.requestIgnorer)))
+	}
+	if cfg.handleErrors {
+		// The spans are marked as errors by spanMiddleware, from the final
+		// status of the response.
+		opts = append(opts, ddEcho.WithStatusCheck(func(int) bool { return false }))
+	} else if cfg.isErrorStatus != nil {
+		opts = append(opts, ddEcho.WithStatusCheck(cfg.isErrorStatus))
+	}
+	for k, v := range cfg.tags {
+		opts = append(opts, ddEcho.WithCustomTag(k, v))
+	}
+	return opts
+}
+
+// Option allows for overriding our default-config.
+type Option func(cfg *config)
+
+// WithServiceName overrides the service-name set in environment-variable "DD_SERVICE".
+func WithServiceName(serviceName string) Option {
+	return func(cfg *config) {
+		cfg.serviceName = serviceName
+	}
+}
+
+// WithResourceNamer specifies a function that will be called to determine what
+// the ResourceName of the span should be. By default, RouteResourceNamer is
+// used.
+func WithResourceNamer(resourceNamer ResourceNamer) Option {
+	return func(cfg *config) {
+		cfg.resourceNamer = resourceNamer
+	}
+}
+
+// WithRequestIgnorer specifies a function that will be called to determined if
+// the request should be traced or not, such as requests to "/healthz" from
+// Kubernetes probes.
+func WithRequestIgnorer(requestIgnorer RequestIgnorer) Option {
+	return func(cfg *config) {
+		cfg.requestIgnorer = requestIgnorer
+	}
+}
+
+// WithCustomTag will attach the value to the span tagged by the key.
+func WithCustomTag(key string, value any) Option {
+	return func(cfg *config) {
+		if cfg.tags == nil {
+			cfg.tags = make(map[string]any)
+		}
+		cfg.tags[key] = value
+	}
+}
+
+// WithErrorStatuses specifies a function that will be called with the status
+// code of every response, to determine if the span should be marked as an
+// error. For example, a 404 from a lookup API can be treated as a success, or a
+// 429 as an error. By default, 5xx responses are marked as errors.
+func WithErrorStatuses(isErrorStatus func(status int) bool) Option {
+	return func(cfg *config) {
+		cfg.isErrorStatus = isErrorStatus
+	}
+}
+
+// WithRequestHeaderTags will attach the values of the request headers to the
+// span, tagged by "http.request.headers.<header>". Headers that are not in
+// the list are never attached, and headers missing from a request are skipped.
+func WithRequestHeaderTags(headers ...string) Option {
+	return func(cfg *config) {
+		cfg.requestHeaders = append(cfg.requestHeaders, headers...)
+	}
+}
+
+// WithResponseHeaderTags will attach the values of the response headers to
+// the span, tagged by "http.response.headers.<header>". Headers that are not in
+// the list are never attached, and headers missing from a response are
+// skipped.
+func WithResponseHeaderTags(headers ...string) Option {
+	return func(cfg *config) {
+		cfg.responseHeaders = append(cfg.responseHeaders, headers...)
+	}
+}
+
+// WithHashedTagValues specifies the headers, among the ones attached with
+// WithRequestHeaderTags and WithResponseHeaderTags, whose values are hashed
+// before they are attached. Hashed values can be used to correlate requests,
+// such as all the requests of one user, without exposing the values. Names are
+// case-insensitive.
+func WithHashedTagValues(names ...string) Option {
+	return func(cfg *config) {
+		if cfg.hashedValues == nil {
+			cfg.hashedValues = make(map[string]struct{})
+		}
+		for _, name := range names {
+			cfg.hashedValues[strings.ToLower(name)] = struct{}{}
+		}
+	}
+}
+
+// WithErrorHandling makes Wrap pass the errors returned by the handlers to the
+// error handler of Echo, [echo.Echo.HTTPErrorHandler], inside the span of the
+// request, like the Recover middleware of Echo does. The status written by the
+// error handler, and the error itself, are then recorded on the span, even
+// when the error handler maps errors to other statuses. The errors are handled
+// at that point, so middlewares registered before Wrap, with [echo.Echo.Use]
+// or [echo.Echo.Pre], see a nil error instead. Only valid for Wrap.
+func WithErrorHandling() Option {
+	return func(cfg *config) {
+		cfg.handleErrors = true
+	}
+}
+
+// RouteResourceNamer will name the ResourceName to "GET /orders/:id", from
+// the route that matched the request, so that identifiers in the path are not
+// part of the resource name. This is the default.
+func RouteResourceNamer() ResourceNamer {
+	return func(c echo.Context) string {
+		return c.Request().Method + " " + c.Path()
+	}
+}
+
+// StaticResourceNamer will set every span's ResourceName to str.
+func StaticResourceNamer(str string) ResourceNamer {
+	return func(_ echo.Context) string {
+		return str
+	}
+}
+
+// spanMiddleware returns a middleware that applies the options that are not
+// supported by ddEcho to the span of the request. It must be registered after
+// the ddEcho middleware, so that the span is in the request's context.
+func (cfg *config) spanMiddleware() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			if cfg.requestIgnorer != nil && cfg.requestIgnorer(c) {
+				return next(c)
+			}
+			span, ok := tracer.SpanFromContext(c.Request().Context())
+			if !ok {
+				return next(c)
+			}
+			cfg.tagHeaders(span, requestHeaderTagPrefix, cfg.requestHeaders, c.Request().Header)
+			err := next(c)
+			if err != nil && cfg.handleErrors {
+				c.Error(err)
+			}
+			if cfg.resourceNamer != nil {
+				span.SetTag(ext.ResourceName, cfg.resourceNamer(c))
+			}
+			cfg.tagHeaders(span, responseHeaderTagPrefix, cfg.responseHeaders, c.Response().Header())
+			if !cfg.handleErrors {
+				// ddEcho records the error, and the status derived from it.
+				return err
+			}
+			if status := responseStatus(c, err); cfg.isError(status) && c.Get(errorRecordedKey) == nil {
+				if err != nil {
+					recordError(c, span, err)
+				} else {
+					span.SetTag(ext.ErrorNoStackTrace, fmt.Errorf("%d: %s", status, http.StatusText(status)))
+				}
+			}
+			return nil
+		}
+	}
+}
+
+func (cfg *config) tagHeaders(span *tracer.Span, prefix string, names []string, header http.Header) {
+	for _, name := range names {
+		if values := header.Values(name); len(values) > 0 {
+			span.SetTag(prefix+strings.ToLower(name), cfg.tagValue(name, values))
+		}
+	}
+}
+
+// tagValue joins the values, and hashes them if the name is in
+// WithHashedTagValues.
+func (cfg *config) tagValue(name string, values []string) string {
+	value := strings.Join(values, ",")
+	if _, ok := cfg.hashedValues[strings.ToLower(name)]; ok {
+		return internal.HashTagValue(value)
+	}
+	return value
+}
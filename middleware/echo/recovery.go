@@ -0,0 +1,45 @@
+package echo
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/coopnorge/go-datadog-lib/v2/internal/recovery"
+	"github.com/labstack/echo/v4"
+)
+
+// MetricPanics is the name of the metric counting the panics recovered by
+// RecoveryMiddleware.
+const MetricPanics = recovery.MetricPanics
+
+// RecoveryMiddleware returns a middleware that recovers panics in the handler,
+// and returns an [echo.HTTPError] with the status 500 instead. The span of
+// the request is marked as an error with the stack trace of the panic, the
+// metric "panics" is incremented, tagged with the route, and the panic is
+// logged with the trace of the request. Register it after [Wrap] or
+// [TraceServerMiddleware], so that the span of the request is in the context.
+func RecoveryMiddleware() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) (err error) {
+			defer func() {
+				r := recover()
+				if r == nil {
+					return
+				}
+				if abortErr, ok := r.(error); ok && errors.Is(abortErr, http.ErrAbortHandler) {
+					// http.ErrAbortHandler is used to abort a response, and is
+					// not an error.
+					panic(r)
+				}
+				req := c.Request()
+				recovered := recovery.Record(req.Context(), "echo", req.Method+" "+c.Path(), r)
+				err = &echo.HTTPError{
+					Code:     http.StatusInternalServerError,
+					Message:  http.StatusText(http.StatusInternalServerError),
+					Internal: recovered,
+				}
+			}()
+			return next(c)
+		}
+	}
+}
@@ -0,0 +1,40 @@
+package echo_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/DataDog/dd-trace-go/v2/ddtrace/ext"
+	"github.com/DataDog/dd-trace-go/v2/ddtrace/mocktracer"
+	"github.com/coopnorge/go-datadog-lib/v2/internal/testhelpers"
+	"github.com/coopnorge/go-datadog-lib/v2/metrics/metricstest"
+	coopEchoDatadog "github.com/coopnorge/go-datadog-lib/v2/middleware/echo"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecoveryMiddleware(t *testing.T) {
+	testhelpers.ConfigureDatadog(t)
+	testTracer := mocktracer.Start()
+	t.Cleanup(testTracer.Stop)
+	recorder := metricstest.Start(t)
+
+	e := coopEchoDatadog.Wrap(echo.New())
+	e.Use(coopEchoDatadog.RecoveryMiddleware())
+	e.GET("/orders/:id", func(echo.Context) error {
+		panic("boom")
+	})
+
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/orders/12345", nil))
+	assert.Equal(t, http.StatusInternalServerError, rec.Code)
+	assert.NotContains(t, rec.Body.String(), "boom")
+
+	spans := testTracer.FinishedSpans()
+	require.Len(t, spans, 1)
+	assert.NotNil(t, spans[0].Tag(ext.ErrorMsg))
+	assert.Contains(t, spans[0].Tag(ext.ErrorStack), "panic: boom")
+	recorder.AssertCount(t, coopEchoDatadog.MetricPanics, 1, "component:echo", "resource:GET /orders/:id")
+}
@@ -46,3 +46,23 @@ func ExampleNewORM_withDriver() {
 
 	println(tx)
 }
+
+func ExampleNewORM_withTags() {
+	ctx := context.Background()
+	dsn := "example.com/users"
+
+	gormDB, err := ddGorm.NewORM(mysql.Open(dsn), &gorm.Config{},
+		ddGorm.WithCustomTagFunc("db.table", ddGorm.TableName()),
+		ddGorm.WithCustomTagFunc("db.rows_affected", ddGorm.RowsAffected()),
+		ddGorm.WithCustomTagFunc("db.statement", ddGorm.StatementType()),
+		ddGorm.WithRecordNotFoundAsNonError(),
+	)
+	if err != nil {
+		panic(err)
+	}
+
+	user := &User{}
+	tx := gormDB.WithContext(ctx).Select("*").First(user)
+
+	println(tx)
+}
@@ -1,7 +1,9 @@
 package gorm
 
 import (
+	"errors"
 	"os"
+	"strings"
 
 	gormtrace "github.com/DataDog/dd-trace-go/contrib/gorm.io/gorm.v1/v2"
 	"github.com/coopnorge/go-datadog-lib/v2/internal"
@@ -19,16 +21,24 @@ func NewORM(dialector gorm.Dialector, gormCfg *gorm.Config, options ...Option) (
 	for _, opt := range options {
 		opt(cfg)
 	}
-	opts := make([]gormtrace.Option, 0, 2)
+	opts := make([]gormtrace.Option, 0, 2+len(cfg.tags)+len(cfg.tagFuncs))
 	if cfg.serviceName != "" {
 		opts = append(opts, gormtrace.WithService(cfg.serviceName))
 	}
+	if cfg.ignoreRecordNotFound {
+		opts = append(opts, gormtrace.WithErrorCheck(func(err error) bool {
+			return !errors.Is(err, gorm.ErrRecordNotFound)
+		}))
+	}
 	for k, v := range cfg.tags {
 		staticTagger := func(_ *gorm.DB) any {
 			return v
 		}
 		opts = append(opts, gormtrace.WithCustomTag(k, staticTagger))
 	}
+	for k, fn := range cfg.tagFuncs {
+		opts = append(opts, gormtrace.WithCustomTag(k, fn))
+	}
 
 	if gormCfg == nil {
 		// gormtrace panics if gormCfg is nil
@@ -39,8 +49,10 @@ func NewORM(dialector gorm.Dialector, gormCfg *gorm.Config, options ...Option) (
 }
 
 type config struct {
-	serviceName string
-	tags        map[string]any
+	serviceName          string
+	tags                 map[string]any
+	tagFuncs             map[string]TagFunc
+	ignoreRecordNotFound bool
 }
 
 func defaults() *config {
@@ -70,3 +82,58 @@ func WithCustomTag(key string, value any) Option {
 		cfg.tags[key] = value
 	}
 }
+
+// TagFunc is a function that will be called with the *gorm.DB of every query,
+// to determine the value of a tag.
+type TagFunc func(db *gorm.DB) any
+
+// WithCustomTagFunc will attach the value returned by fn for every query to
+// the span tagged by the key. See TableName, RowsAffected and StatementType
+// for built-in functions.
+func WithCustomTagFunc(key string, fn TagFunc) Option {
+	return func(cfg *config) {
+		if cfg.tagFuncs == nil {
+			cfg.tagFuncs = make(map[string]TagFunc)
+		}
+		cfg.tagFuncs[key] = fn
+	}
+}
+
+// WithRecordNotFoundAsNonError will not mark spans as errors when the query
+// returns gorm.ErrRecordNotFound, such as when First finds no rows, which is
+// often an expected result rather than a failure.
+func WithRecordNotFoundAsNonError() Option {
+	return func(cfg *config) {
+		cfg.ignoreRecordNotFound = true
+	}
+}
+
+// TableName returns the name of the table of the query, such as "users".
+// Raw queries have no table name.
+func TableName() TagFunc {
+	return func(db *gorm.DB) any {
+		if db.Statement == nil {
+			return ""
+		}
+		return db.Statement.Table
+	}
+}
+
+// RowsAffected returns the number of rows affected or returned by the query.
+func RowsAffected() TagFunc {
+	return func(db *gorm.DB) any {
+		return db.RowsAffected
+	}
+}
+
+// StatementType returns the type of the statement of the query, from its
+// first keyword, such as "SELECT" or "INSERT".
+func StatementType() TagFunc {
+	return func(db *gorm.DB) any {
+		if db.Statement == nil {
+			return ""
+		}
+		keyword, _, _ := strings.Cut(strings.TrimSpace(db.Statement.SQL.String()), " ")
+		return strings.ToUpper(keyword)
+	}
+}
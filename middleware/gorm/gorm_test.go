@@ -0,0 +1,132 @@
+package gorm_test
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"io"
+	"testing"
+
+	"github.com/DataDog/dd-trace-go/v2/ddtrace/ext"
+	"github.com/DataDog/dd-trace-go/v2/ddtrace/mocktracer"
+	"github.com/coopnorge/go-datadog-lib/v2/internal/testhelpers"
+	ddGorm "github.com/coopnorge/go-datadog-lib/v2/middleware/gorm"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+)
+
+func TestTagFuncs(t *testing.T) {
+	db := &gorm.DB{Statement: &gorm.Statement{Table: "users"}, RowsAffected: 3}
+	db.Statement.SQL.WriteString("select * from `users` where `id` = ?")
+
+	assert.Equal(t, "users", ddGorm.TableName()(db))
+	assert.EqualValues(t, 3, ddGorm.RowsAffected()(db))
+	assert.Equal(t, "SELECT", ddGorm.StatementType()(db))
+
+	empty := &gorm.DB{}
+	assert.Equal(t, "", ddGorm.TableName()(empty))
+	assert.Equal(t, "", ddGorm.StatementType()(empty))
+}
+
+type user struct {
+	ID   int
+	Name string
+}
+
+func TestNewORMTags(t *testing.T) {
+	testhelpers.ConfigureDatadog(t)
+	testTracer := mocktracer.Start()
+	t.Cleanup(testTracer.Stop)
+
+	db := newORM(t,
+		ddGorm.WithCustomTagFunc("db.table", ddGorm.TableName()),
+		ddGorm.WithCustomTagFunc("db.rows_affected", ddGorm.RowsAffected()),
+		ddGorm.WithCustomTagFunc("db.statement", ddGorm.StatementType()),
+	)
+
+	err := db.Model(&user{ID: 1}).Update("name", "Ola").Error
+	require.NoError(t, err)
+
+	spans := testTracer.FinishedSpans()
+	require.Len(t, spans, 1)
+	assert.Equal(t, "users", spans[0].Tag("db.table"))
+	assert.EqualValues(t, 2, spans[0].Tag("db.rows_affected"))
+	assert.Equal(t, "UPDATE", spans[0].Tag("db.statement"))
+	assert.Nil(t, spans[0].Tag(ext.ErrorMsg))
+}
+
+func TestNewORMRecordNotFound(t *testing.T) {
+	tests := []struct {
+		name      string
+		options   []ddGorm.Option
+		wantError bool
+	}{
+		{"Default", nil, true},
+		{"Record not found as non-error", []ddGorm.Option{ddGorm.WithRecordNotFoundAsNonError()}, false},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			testhelpers.ConfigureDatadog(t)
+			testTracer := mocktracer.Start()
+			t.Cleanup(testTracer.Stop)
+
+			db := newORM(t, tc.options...)
+
+			var u user
+			err := db.First(&u, 1).Error
+			require.ErrorIs(t, err, gorm.ErrRecordNotFound)
+
+			spans := testTracer.FinishedSpans()
+			require.Len(t, spans, 1)
+			if tc.wantError {
+				assert.Equal(t, gorm.ErrRecordNotFound.Error(), spans[0].Tag(ext.ErrorMsg))
+			} else {
+				assert.Nil(t, spans[0].Tag(ext.ErrorMsg))
+			}
+		})
+	}
+}
+
+// newORM opens gorm through NewORM, with a database that returns no rows from
+// queries and reports two affected rows from other statements.
+func newORM(t *testing.T, options ...ddGorm.Option) *gorm.DB {
+	t.Helper()
+	sqlDB := sql.OpenDB(fakeConnector{})
+	t.Cleanup(func() { _ = sqlDB.Close() })
+	dialector := mysql.New(mysql.Config{Conn: sqlDB, SkipInitializeWithVersion: true})
+	db, err := ddGorm.NewORM(dialector, &gorm.Config{}, options...)
+	require.NoError(t, err)
+	return db
+}
+
+type fakeConnector struct{}
+
+func (fakeConnector) Connect(context.Context) (driver.Conn, error) { return fakeConn{}, nil }
+func (fakeConnector) Driver() driver.Driver                        { return nil }
+
+type fakeConn struct{}
+
+func (fakeConn) Prepare(string) (driver.Stmt, error) { return nil, driver.ErrSkip }
+func (fakeConn) Close() error                        { return nil }
+func (fakeConn) Begin() (driver.Tx, error)           { return fakeTx{}, nil }
+
+func (fakeConn) ExecContext(context.Context, string, []driver.NamedValue) (driver.Result, error) {
+	return driver.RowsAffected(2), nil
+}
+
+func (fakeConn) QueryContext(context.Context, string, []driver.NamedValue) (driver.Rows, error) {
+	return fakeRows{}, nil
+}
+
+type fakeTx struct{}
+
+func (fakeTx) Commit() error   { return nil }
+func (fakeTx) Rollback() error { return nil }
+
+type fakeRows struct{}
+
+func (fakeRows) Columns() []string         { return []string{"id", "name"} }
+func (fakeRows) Close() error              { return nil }
+func (fakeRows) Next([]driver.Value) error { return io.EOF }
@@ -27,8 +27,21 @@ func UnaryClientInterceptor(options ...Option) grpc.UnaryClientInterceptor {
 		return noOpUnaryClientInterceptor()
 	}
 
-	opts := convertOptions(options...)
-	return ddGrpc.UnaryClientInterceptor(opts...)
+	cfg := newConfig(options...)
+	interceptor := ddGrpc.UnaryClientInterceptor(convertOptions(cfg)...)
+	return func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		if cfg.isUntraced(method) {
+			return invoker(ctx, method, req, reply, cc, opts...)
+		}
+		if len(cfg.metadataTags) > 0 {
+			next := invoker
+			invoker = func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+				cfg.tagOutgoingMetadata(ctx)
+				return next(ctx, method, req, reply, cc, opts...)
+			}
+		}
+		return interceptor(ctx, method, req, reply, cc, invoker, opts...)
+	}
 }
 
 // StreamClientInterceptor create a client-interceptor to automatically create child-spans, and append to gRPC metadata.
@@ -36,8 +49,21 @@ func StreamClientInterceptor(options ...Option) grpc.StreamClientInterceptor {
 	if internal.IsDatadogDisabled() {
 		return noOpStreamClientInterceptor()
 	}
-	opts := convertOptions(options...)
-	return ddGrpc.StreamClientInterceptor(opts...)
+	cfg := newConfig(options...)
+	interceptor := ddGrpc.StreamClientInterceptor(convertOptions(cfg)...)
+	return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		if cfg.isUntraced(method) {
+			return streamer(ctx, desc, cc, method, opts...)
+		}
+		if len(cfg.metadataTags) > 0 {
+			next := streamer
+			streamer = func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+				cfg.tagOutgoingMetadata(ctx)
+				return next(ctx, desc, cc, method, opts...)
+			}
+		}
+		return interceptor(ctx, desc, cc, method, streamer, opts...)
+	}
 }
 
 func noOpStreamClientInterceptor() grpc.StreamClientInterceptor {
@@ -8,9 +8,11 @@ import (
 	"strconv"
 	"strings"
 	"testing"
+	"time"
 
 	datadogMiddleware "github.com/coopnorge/go-datadog-lib/v2/middleware/grpc"
 
+	"github.com/coopnorge/go-datadog-lib/v2/internal"
 	"github.com/coopnorge/go-datadog-lib/v2/internal/testhelpers"
 
 	"github.com/DataDog/dd-trace-go/v2/ddtrace/mocktracer"
@@ -192,3 +194,97 @@ func TestStreamClientInterceptor(t *testing.T) {
 		}
 	}
 }
+
+func TestUnaryClientInterceptorWithRequestMetadataTags(t *testing.T) {
+	testhelpers.ConfigureDatadog(t)
+	testTracer := mocktracer.Start()
+	t.Cleanup(testTracer.Stop)
+
+	interceptor := datadogMiddleware.UnaryClientInterceptor(
+		datadogMiddleware.WithRequestMetadataTags("x-tenant"),
+	)
+	ctx := metadata.AppendToOutgoingContext(context.Background(), "x-tenant", "coop-norge", "x-user-id", "user-42")
+	err := interceptor(ctx, "/coop.Orders/Get", nil, nil, nil, func(_ context.Context, _ string, _, _ any, _ *grpc.ClientConn, _ ...grpc.CallOption) error {
+		return nil
+	})
+	require.NoError(t, err)
+
+	spans := testTracer.FinishedSpans()
+	require.Len(t, spans, 1)
+	assert.Equal(t, "coop-norge", spans[0].Tag("grpc.request.metadata.x-tenant"))
+	assert.Nil(t, spans[0].Tag("grpc.request.metadata.x-user-id"))
+}
+
+func TestInterceptorsWithMetadataTags(t *testing.T) {
+	testhelpers.ConfigureDatadog(t)
+	testTracer := mocktracer.Start()
+	t.Cleanup(testTracer.Stop)
+
+	ddOpts := []datadogMiddleware.Option{
+		datadogMiddleware.WithMetadataTags(map[string]string{
+			"X-Request-Id": "request_id",
+			"x-tenant":     "tenant",
+			"x-user-id":    "usr.id",
+		}),
+		datadogMiddleware.WithHashedTagValues("x-user-id"),
+	}
+
+	listener := bufconn.Listen(bufSize)
+	grpcServer := grpc.NewServer(
+		grpc.UnaryInterceptor(datadogMiddleware.UnaryServerInterceptor(ddOpts...)),
+		grpc.StreamInterceptor(datadogMiddleware.StreamServerInterceptor(ddOpts...)),
+	)
+	testgrpc.RegisterTestServiceServer(grpcServer, &testServer{})
+	go func() {
+		_ = grpcServer.Serve(listener)
+	}()
+	t.Cleanup(grpcServer.Stop)
+
+	conn, err := grpc.NewClient("dns:///localhost",
+		grpc.WithContextDialer(func(_ context.Context, _ string) (net.Conn, error) { return listener.Dial() }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithUnaryInterceptor(datadogMiddleware.UnaryClientInterceptor(ddOpts...)),
+		grpc.WithStreamInterceptor(datadogMiddleware.StreamClientInterceptor(ddOpts...)),
+	)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = conn.Close() })
+	client := testgrpc.NewTestServiceClient(conn)
+
+	ctx := metadata.AppendToOutgoingContext(context.Background(),
+		"x-request-id", "abc-123",
+		"x-user-id", "user-42",
+	)
+
+	assertTags := func(t *testing.T, spans []*mocktracer.Span) {
+		t.Helper()
+		var kinds []string
+		for _, span := range spans {
+			if span.OperationName() == "grpc.message" {
+				continue
+			}
+			kinds = append(kinds, span.Tag("span.kind").(string))
+			assert.Equal(t, "abc-123", span.Tag("request_id"))
+			assert.Equal(t, internal.HashTagValue("user-42"), span.Tag("usr.id"))
+			assert.Nil(t, span.Tag("tenant"))
+		}
+		assert.ElementsMatch(t, []string{"client", "server"}, kinds)
+	}
+
+	t.Run("Unary", func(t *testing.T) {
+		testTracer.Reset()
+		_, err := client.EmptyCall(ctx, &testgrpc.Empty{})
+		require.NoError(t, err)
+		assertTags(t, testTracer.FinishedSpans())
+	})
+
+	t.Run("Stream", func(t *testing.T) {
+		testTracer.Reset()
+		stream, err := client.StreamingOutputCall(ctx, &testgrpc.StreamingOutputCallRequest{})
+		require.NoError(t, err)
+		_, err = stream.Recv()
+		require.ErrorIs(t, err, io.EOF)
+		// The client span is finished asynchronously, when the stream's context is done.
+		assert.Eventually(t, func() bool { return len(testTracer.OpenSpans()) == 0 }, time.Second, 10*time.Millisecond)
+		assertTags(t, testTracer.FinishedSpans())
+	})
+}
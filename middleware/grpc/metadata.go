@@ -0,0 +1,49 @@
+package grpc
+
+import (
+	"context"
+	"strings"
+
+	"github.com/DataDog/dd-trace-go/v2/ddtrace/tracer"
+	"github.com/coopnorge/go-datadog-lib/v2/internal"
+	"google.golang.org/grpc/metadata"
+)
+
+// requestMetadataTagPrefix is the prefix of the tags attached by
+// WithRequestMetadataTags.
+const requestMetadataTagPrefix = "grpc.request.metadata."
+
+// tagIncomingMetadata attaches the metadata keys of WithRequestMetadataTags
+// and WithMetadataTags, from the incoming metadata, to the span in the context.
+func (cfg *config) tagIncomingMetadata(ctx context.Context) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	cfg.tagMetadata(ctx, md)
+}
+
+// tagOutgoingMetadata attaches the metadata keys of WithRequestMetadataTags
+// and WithMetadataTags, from the outgoing metadata, to the span in the context.
+func (cfg *config) tagOutgoingMetadata(ctx context.Context) {
+	md, _ := metadata.FromOutgoingContext(ctx)
+	cfg.tagMetadata(ctx, md)
+}
+
+func (cfg *config) tagMetadata(ctx context.Context, md metadata.MD) {
+	if len(md) == 0 {
+		return
+	}
+	span, ok := tracer.SpanFromContext(ctx)
+	if !ok {
+		return
+	}
+	for key, tag := range cfg.metadataTags {
+		values := md.Get(key)
+		if len(values) == 0 {
+			continue
+		}
+		value := strings.Join(values, ",")
+		if _, ok := cfg.hashedValues[key]; ok {
+			value = internal.HashTagValue(value)
+		}
+		span.SetTag(tag, value)
+	}
+}
@@ -0,0 +1,50 @@
+package grpc
+
+import (
+	"strings"
+)
+
+// defaultUntracedMethods are ignored by the interceptors, in addition to the
+// methods of WithUntracedMethods, unless WithoutDefaultUntracedMethods is
+// used, since health probes and reflection would flood the traces.
+var defaultUntracedMethods = []string{
+	"/grpc.health.v1.Health/*",
+	"/grpc.reflection.*",
+}
+
+// isUntraced reports whether the request to the full method should be ignored
+// by the interceptors.
+func (cfg *config) isUntraced(fullMethod string) bool {
+	for _, pattern := range cfg.untracedMethods {
+		if matchMethod(pattern, fullMethod) {
+			return true
+		}
+	}
+	if !cfg.noDefaultUntraced {
+		for _, pattern := range defaultUntracedMethods {
+			if matchMethod(pattern, fullMethod) {
+				return true
+			}
+		}
+	}
+	return cfg.methodIgnorer != nil && cfg.methodIgnorer(fullMethod)
+}
+
+// matchMethod reports whether the full method matches the pattern, where "*"
+// matches any sequence of characters, including "/".
+func matchMethod(pattern, fullMethod string) bool {
+	prefix, rest, ok := strings.Cut(pattern, "*")
+	if !ok {
+		return pattern == fullMethod
+	}
+	if !strings.HasPrefix(fullMethod, prefix) {
+		return false
+	}
+	fullMethod = fullMethod[len(prefix):]
+	for i := len(fullMethod); i >= 0; i-- {
+		if matchMethod(rest, fullMethod[i:]) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,179 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+	"io"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/coopnorge/go-datadog-lib/v2/internal"
+	"github.com/coopnorge/go-datadog-lib/v2/metrics"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Names of the metrics sent by the metrics interceptors.
+const (
+	MetricServerRequests = "grpc.server.requests"
+	MetricServerErrors   = "grpc.server.errors"
+	MetricServerDuration = "grpc.server.duration"
+	MetricClientRequests = "grpc.client.requests"
+	MetricClientErrors   = "grpc.client.errors"
+	MetricClientDuration = "grpc.client.duration"
+)
+
+type metricNames struct {
+	requests string
+	errors   string
+	duration string
+}
+
+var (
+	serverMetricNames = metricNames{requests: MetricServerRequests, errors: MetricServerErrors, duration: MetricServerDuration}
+	clientMetricNames = metricNames{requests: MetricClientRequests, errors: MetricClientErrors, duration: MetricClientDuration}
+)
+
+// UnaryServerMetricsInterceptor returns a middleware that sends metrics for
+// every incoming request: the number of requests, the number of failed
+// requests and the duration of each request in milliseconds, as a
+// distribution. The metrics are tagged with "grpc.service", "grpc.method" and
+// "grpc.code", such as "NotFound". Requests whose code is in WithNonErrorCodes
+// are not counted as failed, and methods in WithUntracedMethods are skipped.
+func UnaryServerMetricsInterceptor(options ...Option) grpc.UnaryServerInterceptor {
+	if internal.IsDatadogDisabled() {
+		return noOpUnaryServerInterceptor()
+	}
+	cfg := newConfig(options...)
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		if cfg.isUntraced(info.FullMethod) {
+			return handler(ctx, req)
+		}
+		start := time.Now()
+		resp, err := handler(ctx, req)
+		cfg.recordMetrics(ctx, serverMetricNames, info.FullMethod, start, err)
+		return resp, err
+	}
+}
+
+// StreamServerMetricsInterceptor returns a middleware that sends metrics for
+// every incoming stream. See UnaryServerMetricsInterceptor.
+func StreamServerMetricsInterceptor(options ...Option) grpc.StreamServerInterceptor {
+	if internal.IsDatadogDisabled() {
+		return noOpStreamServerInterceptor()
+	}
+	cfg := newConfig(options...)
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if cfg.isUntraced(info.FullMethod) {
+			return handler(srv, ss)
+		}
+		start := time.Now()
+		err := handler(srv, ss)
+		cfg.recordMetrics(ss.Context(), serverMetricNames, info.FullMethod, start, err)
+		return err
+	}
+}
+
+// UnaryClientMetricsInterceptor returns a middleware that sends metrics for
+// every outgoing request. See UnaryServerMetricsInterceptor.
+func UnaryClientMetricsInterceptor(options ...Option) grpc.UnaryClientInterceptor {
+	if internal.IsDatadogDisabled() {
+		return noOpUnaryClientInterceptor()
+	}
+	cfg := newConfig(options...)
+	return func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		if cfg.isUntraced(method) {
+			return invoker(ctx, method, req, reply, cc, opts...)
+		}
+		start := time.Now()
+		err := invoker(ctx, method, req, reply, cc, opts...)
+		cfg.recordMetrics(ctx, clientMetricNames, method, start, err)
+		return err
+	}
+}
+
+// StreamClientMetricsInterceptor returns a middleware that sends metrics for
+// every outgoing stream. See UnaryServerMetricsInterceptor. The metrics are
+// sent when the stream ends, which is when RecvMsg returns an error, or the
+// response of a stream that is not server-streaming is received.
+func StreamClientMetricsInterceptor(options ...Option) grpc.StreamClientInterceptor {
+	if internal.IsDatadogDisabled() {
+		return noOpStreamClientInterceptor()
+	}
+	cfg := newConfig(options...)
+	return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		if cfg.isUntraced(method) {
+			return streamer(ctx, desc, cc, method, opts...)
+		}
+		start := time.Now()
+		stream, err := streamer(ctx, desc, cc, method, opts...)
+		if err != nil {
+			cfg.recordMetrics(ctx, clientMetricNames, method, start, err)
+			return nil, err
+		}
+		return &metricsClientStream{
+			ClientStream:  stream,
+			serverStreams: desc.ServerStreams,
+			finish: func(err error) {
+				cfg.recordMetrics(ctx, clientMetricNames, method, start, err)
+			},
+		}, nil
+	}
+}
+
+// metricsClientStream sends the metrics of the stream when it ends.
+type metricsClientStream struct {
+	grpc.ClientStream
+	serverStreams bool
+	finish        func(err error)
+	finishOnce    sync.Once
+}
+
+func (s *metricsClientStream) RecvMsg(m any) error {
+	err := s.ClientStream.RecvMsg(m)
+	switch {
+	case errors.Is(err, io.EOF):
+		s.finishOnce.Do(func() { s.finish(nil) })
+	case err != nil:
+		s.finishOnce.Do(func() { s.finish(err) })
+	case !s.serverStreams:
+		// The single response of the stream has been received.
+		s.finishOnce.Do(func() { s.finish(nil) })
+	}
+	return err
+}
+
+// recordMetrics sends the metrics of a request to the full method.
+func (cfg *config) recordMetrics(ctx context.Context, names metricNames, fullMethod string, start time.Time, err error) {
+	elapsed := time.Since(start)
+	code := status.Code(err)
+	service, method := splitFullMethod(fullMethod)
+	opts := []metrics.Option{
+		metrics.WithTag("grpc.service", service),
+		metrics.WithTag("grpc.method", method),
+		metrics.WithTag("grpc.code", code.String()),
+	}
+	metrics.IncrCtx(ctx, names.requests, opts...)
+	if cfg.isErrorCode(code) {
+		metrics.IncrCtx(ctx, names.errors, opts...)
+	}
+	metrics.DistributionCtx(ctx, names.duration, float64(elapsed)/float64(time.Millisecond), opts...)
+}
+
+// isErrorCode reports whether the code should be counted as an error.
+func (cfg *config) isErrorCode(code codes.Code) bool {
+	return code != codes.OK && !slices.Contains(cfg.nonErrorCodes, code)
+}
+
+// splitFullMethod splits "/package.Service/Method" into the service and the
+// method.
+func splitFullMethod(fullMethod string) (service, method string) {
+	service, method, ok := strings.Cut(strings.TrimPrefix(fullMethod, "/"), "/")
+	if !ok {
+		return "unknown", service
+	}
+	return service, method
+}
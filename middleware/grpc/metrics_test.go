@@ -0,0 +1,103 @@
+package grpc_test
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"github.com/coopnorge/go-datadog-lib/v2/internal/testhelpers"
+	"github.com/coopnorge/go-datadog-lib/v2/metrics/metricstest"
+	datadogMiddleware "github.com/coopnorge/go-datadog-lib/v2/middleware/grpc"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	testgrpc "google.golang.org/grpc/interop/grpc_testing"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+type metricsTestServer struct {
+	testgrpc.UnimplementedTestServiceServer
+}
+
+func (s *metricsTestServer) UnaryCall(context.Context, *testgrpc.SimpleRequest) (*testgrpc.SimpleResponse, error) {
+	return &testgrpc.SimpleResponse{}, nil
+}
+
+func (s *metricsTestServer) EmptyCall(context.Context, *testgrpc.Empty) (*testgrpc.Empty, error) {
+	return nil, status.Error(codes.NotFound, "not found")
+}
+
+func (s *metricsTestServer) StreamingOutputCall(_ *testgrpc.StreamingOutputCallRequest, stream grpc.ServerStreamingServer[testgrpc.StreamingOutputCallResponse]) error {
+	if err := stream.Send(&testgrpc.StreamingOutputCallResponse{}); err != nil {
+		return err
+	}
+	return status.Error(codes.Unavailable, "unavailable")
+}
+
+func TestMetricsInterceptors(t *testing.T) {
+	testhelpers.ConfigureDatadog(t)
+	recorder := metricstest.Start(t)
+
+	ddOpts := []datadogMiddleware.Option{
+		datadogMiddleware.WithNonErrorCodes(codes.Canceled, codes.NotFound),
+		datadogMiddleware.WithUntracedMethods("/grpc.testing.TestService/StreamingInputCall"),
+	}
+
+	listener := bufconn.Listen(bufSize)
+	grpcServer := grpc.NewServer(
+		grpc.UnaryInterceptor(datadogMiddleware.UnaryServerMetricsInterceptor(ddOpts...)),
+		grpc.StreamInterceptor(datadogMiddleware.StreamServerMetricsInterceptor(ddOpts...)),
+	)
+	testgrpc.RegisterTestServiceServer(grpcServer, &metricsTestServer{})
+	go func() {
+		_ = grpcServer.Serve(listener)
+	}()
+	t.Cleanup(grpcServer.Stop)
+
+	conn, err := grpc.NewClient("dns:///localhost",
+		grpc.WithContextDialer(func(_ context.Context, _ string) (net.Conn, error) { return listener.Dial() }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithUnaryInterceptor(datadogMiddleware.UnaryClientMetricsInterceptor(ddOpts...)),
+		grpc.WithStreamInterceptor(datadogMiddleware.StreamClientMetricsInterceptor(ddOpts...)),
+	)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = conn.Close() })
+	client := testgrpc.NewTestServiceClient(conn)
+	ctx := context.Background()
+
+	_, err = client.UnaryCall(ctx, &testgrpc.SimpleRequest{})
+	require.NoError(t, err)
+	_, err = client.EmptyCall(ctx, &testgrpc.Empty{})
+	require.Equal(t, codes.NotFound, status.Code(err))
+
+	stream, err := client.StreamingOutputCall(ctx, &testgrpc.StreamingOutputCallRequest{})
+	require.NoError(t, err)
+	_, err = stream.Recv()
+	require.NoError(t, err)
+	_, err = stream.Recv()
+	require.Equal(t, codes.Unavailable, status.Code(err))
+
+	inputStream, err := client.StreamingInputCall(ctx)
+	require.NoError(t, err)
+	_, err = inputStream.CloseAndRecv()
+	require.Equal(t, codes.Unimplemented, status.Code(err))
+
+	for _, side := range []struct {
+		requests, errors, duration string
+	}{
+		{datadogMiddleware.MetricServerRequests, datadogMiddleware.MetricServerErrors, datadogMiddleware.MetricServerDuration},
+		{datadogMiddleware.MetricClientRequests, datadogMiddleware.MetricClientErrors, datadogMiddleware.MetricClientDuration},
+	} {
+		recorder.AssertCount(t, side.requests, 1, "grpc.service:grpc.testing.TestService", "grpc.method:UnaryCall", "grpc.code:OK")
+		recorder.AssertCount(t, side.requests, 1, "grpc.method:EmptyCall", "grpc.code:NotFound")
+		recorder.AssertCount(t, side.requests, 1, "grpc.method:StreamingOutputCall", "grpc.code:Unavailable")
+		recorder.AssertCount(t, side.requests, 3)
+		recorder.AssertCount(t, side.errors, 1, "grpc.method:StreamingOutputCall", "grpc.code:Unavailable")
+		recorder.AssertCount(t, side.errors, 1)
+		assert.Len(t, recorder.Find(side.duration), 3)
+		recorder.AssertNotEmitted(t, side.requests, "grpc.method:StreamingInputCall")
+	}
+}
@@ -2,6 +2,7 @@ package grpc
 
 import (
 	"os"
+	"strings"
 
 	ddGrpc "github.com/DataDog/dd-trace-go/contrib/google.golang.org/grpc/v2"
 	"google.golang.org/grpc/codes"
@@ -10,37 +11,41 @@ import (
 // These options, unless otherwise specified, will be valid for both client and server interceptors.
 
 type config struct {
-	serviceName     string
-	nonErrorCodes   []codes.Code
-	untracedMethods []string
-	tags            map[string]any
+	serviceName       string
+	nonErrorCodes     []codes.Code
+	untracedMethods   []string
+	noDefaultUntraced bool
+	methodIgnorer     func(fullMethod string) bool
+	tags              map[string]any
+	metadataTags      map[string]string
+	hashedValues      map[string]struct{}
 }
 
 func defaults() *config {
 	serviceName := os.Getenv("DD_SERVICE")
 	return &config{
-		serviceName:     serviceName,
-		nonErrorCodes:   []codes.Code{codes.Canceled},
-		untracedMethods: nil,
-		tags:            nil,
+		serviceName:   serviceName,
+		nonErrorCodes: []codes.Code{codes.Canceled},
+		tags:          nil,
 	}
 }
 
-func convertOptions(options ...Option) []ddGrpc.Option {
+func newConfig(options ...Option) *config {
 	cfg := defaults()
 	for _, opt := range options {
 		opt(cfg)
 	}
-	opts := make([]ddGrpc.Option, 0, 3+len(cfg.tags))
+	return cfg
+}
+
+func convertOptions(cfg *config) []ddGrpc.Option {
+	opts := make([]ddGrpc.Option, 0, 2+len(cfg.tags))
 	if cfg.serviceName != "" {
 		opts = append(opts, ddGrpc.WithService(cfg.serviceName))
 	}
 	if len(cfg.nonErrorCodes) > 0 {
 		opts = append(opts, ddGrpc.NonErrorCodes(cfg.nonErrorCodes...))
 	}
-	if len(cfg.untracedMethods) > 0 {
-		opts = append(opts, ddGrpc.WithUntracedMethods(cfg.untracedMethods...))
-	}
 	for k, v := range cfg.tags {
 		opts = append(opts, ddGrpc.WithCustomTag(k, v))
 	}
@@ -67,9 +72,30 @@ func WithNonErrorCodes(cs ...codes.Code) Option {
 
 // WithUntracedMethods specifies full methods to be ignored by the server side and client
 // side interceptors. When a request's full method is in 'methods', no spans will be created.
+// A method may be a pattern, where "*" matches any sequence of characters, such as
+// "/grpc.health.v1.Health/*". Health checking and reflection are ignored by default,
+// in addition to the methods, unless WithoutDefaultUntracedMethods is used.
 func WithUntracedMethods(methods ...string) Option {
 	return func(cfg *config) {
-		cfg.untracedMethods = methods
+		cfg.untracedMethods = append(cfg.untracedMethods, methods...)
+	}
+}
+
+// WithoutDefaultUntracedMethods traces health checking and reflection, which
+// are ignored by default. Methods of WithUntracedMethods are still ignored.
+func WithoutDefaultUntracedMethods() Option {
+	return func(cfg *config) {
+		cfg.noDefaultUntraced = true
+	}
+}
+
+// WithMethodIgnorer specifies a function that will be called with the full
+// method of every request, to determine if the request should be ignored by
+// the server side and client side interceptors, in addition to the methods of
+// WithUntracedMethods.
+func WithMethodIgnorer(ignorer func(fullMethod string) bool) Option {
+	return func(cfg *config) {
+		cfg.methodIgnorer = ignorer
 	}
 }
 
@@ -82,3 +108,52 @@ func WithCustomTag(key string, value any) Option {
 		cfg.tags[key] = value
 	}
 }
+
+// WithRequestMetadataTags will attach the values of the request's metadata
+// keys to the span, tagged by "grpc.request.metadata.<key>". For servers, the
+// incoming metadata is used, and for clients the outgoing metadata. Keys that
+// are not in the list are never attached, and keys missing from a request are
+// skipped.
+func WithRequestMetadataTags(keys ...string) Option {
+	return func(cfg *config) {
+		for _, key := range keys {
+			cfg.addMetadataTag(key, requestMetadataTagPrefix+strings.ToLower(key))
+		}
+	}
+}
+
+// WithMetadataTags will attach the values of the request's metadata keys to
+// the span, tagged by the tag each key maps to, such as
+// map[string]string{"x-tenant": "tenant"}. Like WithRequestMetadataTags, the
+// incoming metadata is used for servers, and the outgoing metadata for
+// clients. Use WithHashedTagValues for keys with sensitive values.
+func WithMetadataTags(tags map[string]string) Option {
+	return func(cfg *config) {
+		for key, tag := range tags {
+			cfg.addMetadataTag(key, tag)
+		}
+	}
+}
+
+func (cfg *config) addMetadataTag(key, tag string) {
+	if cfg.metadataTags == nil {
+		cfg.metadataTags = make(map[string]string)
+	}
+	cfg.metadataTags[strings.ToLower(key)] = tag
+}
+
+// WithHashedTagValues specifies the metadata keys, among the ones attached
+// with WithRequestMetadataTags and WithMetadataTags, whose values are hashed before they are
+// attached. Hashed values can be used to correlate requests, such as all the
+// requests of one user, without exposing the values. Keys are
+// case-insensitive.
+func WithHashedTagValues(keys ...string) Option {
+	return func(cfg *config) {
+		if cfg.hashedValues == nil {
+			cfg.hashedValues = make(map[string]struct{})
+		}
+		for _, key := range keys {
+			cfg.hashedValues[strings.ToLower(key)] = struct{}{}
+		}
+	}
+}
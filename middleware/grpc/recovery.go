@@ -0,0 +1,51 @@
+package grpc
+
+import (
+	"context"
+
+	"github.com/coopnorge/go-datadog-lib/v2/internal/recovery"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// MetricPanics is the name of the metric counting the panics recovered by the
+// recovery interceptors.
+const MetricPanics = recovery.MetricPanics
+
+// errRecovered is returned to the client instead of the panic, which might
+// contain internal details.
+var errRecovered = status.Error(codes.Internal, "internal error")
+
+// UnaryServerRecoveryInterceptor returns a middleware that recovers panics in
+// the handler, and returns codes.Internal instead. The span of the request is
+// marked as an error with the stack trace of the panic, the metric "panics"
+// is incremented, tagged with the full method, and the panic is logged with
+// the trace of the request. Chain it after UnaryServerInterceptor, so that
+// the span of the request is in the context.
+func UnaryServerRecoveryInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp any, err error) {
+		defer func() {
+			if r := recover(); r != nil {
+				_ = recovery.Record(ctx, "grpc", info.FullMethod, r)
+				resp, err = nil, errRecovered
+			}
+		}()
+		return handler(ctx, req)
+	}
+}
+
+// StreamServerRecoveryInterceptor returns a middleware that recovers panics in
+// the handler of a stream. See UnaryServerRecoveryInterceptor. Chain it after
+// StreamServerInterceptor.
+func StreamServerRecoveryInterceptor() grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) (err error) {
+		defer func() {
+			if r := recover(); r != nil {
+				_ = recovery.Record(ss.Context(), "grpc", info.FullMethod, r)
+				err = errRecovered
+			}
+		}()
+		return handler(srv, ss)
+	}
+}
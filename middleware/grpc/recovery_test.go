@@ -0,0 +1,66 @@
+package grpc_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/DataDog/dd-trace-go/v2/ddtrace/ext"
+	"github.com/DataDog/dd-trace-go/v2/ddtrace/mocktracer"
+	"github.com/coopnorge/go-datadog-lib/v2/internal/testhelpers"
+	"github.com/coopnorge/go-datadog-lib/v2/metrics/metricstest"
+	datadogMiddleware "github.com/coopnorge/go-datadog-lib/v2/middleware/grpc"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestUnaryServerRecoveryInterceptor(t *testing.T) {
+	testhelpers.ConfigureDatadog(t)
+	testTracer := mocktracer.Start()
+	t.Cleanup(testTracer.Stop)
+	recorder := metricstest.Start(t)
+
+	tracing := datadogMiddleware.UnaryServerInterceptor()
+	recovery := datadogMiddleware.UnaryServerRecoveryInterceptor()
+	info := &grpc.UnaryServerInfo{FullMethod: "/coop.Orders/Get"}
+
+	resp, err := tracing(context.Background(), nil, info, func(ctx context.Context, req any) (any, error) {
+		return recovery(ctx, req, info, func(context.Context, any) (any, error) {
+			panic("boom")
+		})
+	})
+	assert.Nil(t, resp)
+	assert.Equal(t, codes.Internal, status.Code(err))
+	assert.NotContains(t, err.Error(), "boom")
+
+	spans := testTracer.FinishedSpans()
+	require.Len(t, spans, 1)
+	assert.NotNil(t, spans[0].Tag(ext.ErrorMsg))
+	assert.Contains(t, spans[0].Tag(ext.ErrorStack), "panic: boom")
+	assert.Contains(t, spans[0].Tag(ext.ErrorStack), "TestUnaryServerRecoveryInterceptor")
+	recorder.AssertCount(t, datadogMiddleware.MetricPanics, 1, "component:grpc", "resource:/coop.Orders/Get")
+}
+
+func TestStreamServerRecoveryInterceptor(t *testing.T) {
+	testhelpers.ConfigureDatadog(t)
+	recorder := metricstest.Start(t)
+
+	recovery := datadogMiddleware.StreamServerRecoveryInterceptor()
+	info := &grpc.StreamServerInfo{FullMethod: "/coop.Orders/List"}
+	err := recovery(nil, &fakeServerStream{ctx: context.Background()}, info, func(any, grpc.ServerStream) error {
+		panic("boom")
+	})
+	assert.Equal(t, codes.Internal, status.Code(err))
+	recorder.AssertCount(t, datadogMiddleware.MetricPanics, 1, "component:grpc", "resource:/coop.Orders/List")
+}
+
+type fakeServerStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *fakeServerStream) Context() context.Context {
+	return s.ctx
+}
@@ -35,8 +35,21 @@ func UnaryServerInterceptor(options ...Option) grpc.UnaryServerInterceptor {
 	if internal.IsDatadogDisabled() {
 		return noOpUnaryServerInterceptor()
 	}
-	opts := convertOptions(options...)
-	return ddGrpc.UnaryServerInterceptor(opts...)
+	cfg := newConfig(options...)
+	interceptor := ddGrpc.UnaryServerInterceptor(convertOptions(cfg)...)
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		if cfg.isUntraced(info.FullMethod) {
+			return handler(ctx, req)
+		}
+		if len(cfg.metadataTags) > 0 {
+			next := handler
+			handler = func(ctx context.Context, req any) (any, error) {
+				cfg.tagIncomingMetadata(ctx)
+				return next(ctx, req)
+			}
+		}
+		return interceptor(ctx, req, info, handler)
+	}
 }
 
 // StreamServerInterceptor returns a middleware that creates datadog-spans on incoming requests, and stores them in the requests' context.
@@ -44,8 +57,21 @@ func StreamServerInterceptor(options ...Option) grpc.StreamServerInterceptor {
 	if internal.IsDatadogDisabled() {
 		return noOpStreamServerInterceptor()
 	}
-	opts := convertOptions(options...)
-	return ddGrpc.StreamServerInterceptor(opts...)
+	cfg := newConfig(options...)
+	interceptor := ddGrpc.StreamServerInterceptor(convertOptions(cfg)...)
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if cfg.isUntraced(info.FullMethod) {
+			return handler(srv, ss)
+		}
+		if len(cfg.metadataTags) > 0 {
+			next := handler
+			handler = func(srv any, ss grpc.ServerStream) error {
+				cfg.tagIncomingMetadata(ss.Context())
+				return next(srv, ss)
+			}
+		}
+		return interceptor(srv, ss, info, handler)
+	}
 }
 
 func noOpUnaryServerInterceptor() grpc.UnaryServerInterceptor {
@@ -3,8 +3,10 @@ package grpc_test
 import (
 	"context"
 	"net/http"
+	"strings"
 	"testing"
 
+	"github.com/coopnorge/go-datadog-lib/v2/internal"
 	"github.com/coopnorge/go-datadog-lib/v2/internal/testhelpers"
 	datadogMiddleware "github.com/coopnorge/go-datadog-lib/v2/middleware/grpc"
 
@@ -15,6 +17,7 @@ import (
 	"github.com/stretchr/testify/require"
 	"github.com/stretchr/testify/suite"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
 )
 
 func TestTraceUnaryServerInterceptor(t *testing.T) {
@@ -84,3 +87,111 @@ func TestTraceStreamServerInterceptor(t *testing.T) {
 	}
 	suite.Run(t, s)
 }
+
+func TestUnaryServerInterceptorWithRequestMetadataTags(t *testing.T) {
+	testhelpers.ConfigureDatadog(t)
+	testTracer := mocktracer.Start()
+	t.Cleanup(testTracer.Stop)
+
+	interceptor := datadogMiddleware.UnaryServerInterceptor(
+		datadogMiddleware.WithRequestMetadataTags("x-request-id", "x-user-id", "x-missing"),
+		datadogMiddleware.WithHashedTagValues("X-User-Id"),
+	)
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs(
+		"x-request-id", "abc-123",
+		"x-user-id", "user-42",
+		"authorization", "Bearer secret",
+	))
+	_, err := interceptor(ctx, nil, &grpc.UnaryServerInfo{FullMethod: "/coop.Orders/Get"}, func(_ context.Context, _ any) (any, error) {
+		return nil, nil
+	})
+	require.NoError(t, err)
+
+	spans := testTracer.FinishedSpans()
+	require.Len(t, spans, 1)
+	span := spans[0]
+	assert.Equal(t, "abc-123", span.Tag("grpc.request.metadata.x-request-id"))
+	assert.Equal(t, internal.HashTagValue("user-42"), span.Tag("grpc.request.metadata.x-user-id"))
+	assert.Nil(t, span.Tag("grpc.request.metadata.x-missing"))
+	assert.Nil(t, span.Tag("grpc.request.metadata.authorization"))
+}
+
+func TestUnaryServerInterceptorUntracedMethods(t *testing.T) {
+	testhelpers.ConfigureDatadog(t)
+	testTracer := mocktracer.Start()
+	t.Cleanup(testTracer.Stop)
+
+	interceptor := datadogMiddleware.UnaryServerInterceptor(
+		datadogMiddleware.WithUntracedMethods("/coop.Orders/Ping", "/coop.Internal*/Debug*"),
+		datadogMiddleware.WithMethodIgnorer(func(fullMethod string) bool {
+			return strings.HasSuffix(fullMethod, "/Metrics")
+		}),
+	)
+
+	tests := []struct {
+		fullMethod string
+		traced     bool
+	}{
+		{"/coop.Orders/Get", true},
+		{"/coop.Orders/Ping", false},
+		{"/coop.Orders/PingAll", true},
+		{"/coop.InternalAdmin/DebugState", false},
+		{"/coop.InternalAdmin/State", true},
+		{"/coop.Orders/Metrics", false},
+		{"/grpc.health.v1.Health/Check", false},
+		{"/grpc.health.v1.Health/Watch", false},
+		{"/grpc.reflection.v1.ServerReflection/ServerReflectionInfo", false},
+		{"/grpc.reflection.v1alpha.ServerReflection/ServerReflectionInfo", false},
+	}
+	for _, tc := range tests {
+		t.Run(tc.fullMethod, func(t *testing.T) {
+			testTracer.Reset()
+			called := false
+			_, err := interceptor(context.Background(), nil, &grpc.UnaryServerInfo{FullMethod: tc.fullMethod}, func(_ context.Context, _ any) (any, error) {
+				called = true
+				return nil, nil
+			})
+			require.NoError(t, err)
+			assert.True(t, called)
+			if tc.traced {
+				assert.Len(t, testTracer.FinishedSpans(), 1)
+			} else {
+				assert.Empty(t, testTracer.FinishedSpans())
+			}
+		})
+	}
+}
+
+func TestUnaryServerInterceptorWithoutDefaultUntracedMethods(t *testing.T) {
+	testhelpers.ConfigureDatadog(t)
+	testTracer := mocktracer.Start()
+	t.Cleanup(testTracer.Stop)
+
+	interceptor := datadogMiddleware.UnaryServerInterceptor(
+		datadogMiddleware.WithoutDefaultUntracedMethods(),
+		datadogMiddleware.WithUntracedMethods("/coop.Orders/Ping"),
+	)
+
+	tests := []struct {
+		fullMethod string
+		traced     bool
+	}{
+		{"/grpc.health.v1.Health/Check", true},
+		{"/grpc.reflection.v1.ServerReflection/ServerReflectionInfo", true},
+		{"/coop.Orders/Ping", false},
+	}
+	for _, tc := range tests {
+		t.Run(tc.fullMethod, func(t *testing.T) {
+			testTracer.Reset()
+			_, err := interceptor(context.Background(), nil, &grpc.UnaryServerInfo{FullMethod: tc.fullMethod}, func(_ context.Context, _ any) (any, error) {
+				return nil, nil
+			})
+			require.NoError(t, err)
+			if tc.traced {
+				assert.Len(t, testTracer.FinishedSpans(), 1)
+			} else {
+				assert.Empty(t, testTracer.FinishedSpans())
+			}
+		})
+	}
+}
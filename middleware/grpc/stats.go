@@ -0,0 +1,190 @@
+package grpc
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	ddGrpc "github.com/DataDog/dd-trace-go/contrib/google.golang.org/grpc/v2"
+	"github.com/DataDog/dd-trace-go/v2/ddtrace/tracer"
+	"github.com/coopnorge/go-datadog-lib/v2/internal"
+	"google.golang.org/grpc/stats"
+)
+
+// Tags attached by the stats handlers.
+const (
+	// TagMessagesSent is the number of messages sent in the RPC.
+	TagMessagesSent = "grpc.messages.sent"
+	// TagMessagesReceived is the number of messages received in the RPC.
+	TagMessagesReceived = "grpc.messages.received"
+	// TagBytesSent is the total size of the messages sent in the RPC, before
+	// compression.
+	TagBytesSent = "grpc.bytes.sent"
+	// TagBytesReceived is the total size of the messages received in the RPC,
+	// before compression.
+	TagBytesReceived = "grpc.bytes.received"
+	// TagHeaderDuration is the time in milliseconds from the start of the RPC
+	// until the response header was sent by the server, or received by the
+	// client.
+	TagHeaderDuration = "grpc.header.duration_ms"
+	// TagTrailerDuration is the time in milliseconds from the start of the RPC
+	// until the response trailer was sent by the server, or received by the
+	// client.
+	TagTrailerDuration = "grpc.trailer.duration_ms"
+	// TagTransparentRetry is set on the span of a client attempt that was
+	// transparently retried by gRPC.
+	TagTransparentRetry = "grpc.transparent_retry"
+)
+
+// NewServerHandler returns a stats.Handler that creates datadog-spans on
+// incoming requests, and stores them in the requests' context, like
+// UnaryServerInterceptor and StreamServerInterceptor. Unlike the
+// interceptors, the handler does not depend on the order of the interceptor
+// chain, and it tags the spans with the number and size of the messages, and
+// the timing of the response header and trailer. Register it with
+// grpc.StatsHandler.
+func NewServerHandler(options ...Option) stats.Handler {
+	if internal.IsDatadogDisabled() {
+		return noOpStatsHandler{}
+	}
+	cfg := newConfig(options...)
+	return &statsHandler{
+		cfg:     cfg,
+		handler: ddGrpc.NewServerStatsHandler(convertOptions(cfg)...),
+		server:  true,
+	}
+}
+
+// NewClientHandler returns a stats.Handler that creates child-spans for
+// outgoing requests, and appends them to the gRPC metadata, like
+// UnaryClientInterceptor and StreamClientInterceptor. See NewServerHandler.
+// Every attempt of a retried request gets its own span. Register it with
+// grpc.WithStatsHandler.
+func NewClientHandler(options ...Option) stats.Handler {
+	if internal.IsDatadogDisabled() {
+		return noOpStatsHandler{}
+	}
+	cfg := newConfig(options...)
+	return &statsHandler{
+		cfg:     cfg,
+		handler: ddGrpc.NewClientStatsHandler(convertOptions(cfg)...),
+		server:  false,
+	}
+}
+
+// statsHandler wraps the stats.Handler of dd-trace-go, to support the
+// options of the interceptors, and to tag the spans with the stats of the
+// RPC.
+type statsHandler struct {
+	cfg     *config
+	handler stats.Handler
+	server  bool
+}
+
+// rpcStatsKey is the context key of the rpcStats of an RPC.
+type rpcStatsKey struct{}
+
+// rpcStats are the stats of a traced RPC. Messages can be sent and received
+// concurrently in streams.
+type rpcStats struct {
+	begin            time.Time
+	messagesSent     atomic.Int64
+	messagesReceived atomic.Int64
+	bytesSent        atomic.Int64
+	bytesReceived    atomic.Int64
+}
+
+func (h *statsHandler) TagRPC(ctx context.Context, info *stats.RPCTagInfo) context.Context {
+	if h.cfg.isUntraced(info.FullMethodName) {
+		return ctx
+	}
+	ctx = h.handler.TagRPC(ctx, info)
+	if span, ok := tracer.SpanFromContext(ctx); ok {
+		for k, v := range h.cfg.tags {
+			span.SetTag(k, v)
+		}
+	}
+	if len(h.cfg.metadataTags) > 0 {
+		if h.server {
+			h.cfg.tagIncomingMetadata(ctx)
+		} else {
+			h.cfg.tagOutgoingMetadata(ctx)
+		}
+	}
+	return context.WithValue(ctx, rpcStatsKey{}, &rpcStats{begin: time.Now()})
+}
+
+func (h *statsHandler) HandleRPC(ctx context.Context, rs stats.RPCStats) {
+	s, ok := ctx.Value(rpcStatsKey{}).(*rpcStats)
+	if !ok {
+		// The RPC is not traced, and the span in the context, if any,
+		// belongs to the caller.
+		return
+	}
+	span, ok := tracer.SpanFromContext(ctx)
+	if !ok {
+		return
+	}
+	switch rs := rs.(type) {
+	case *stats.Begin:
+		if rs.IsTransparentRetryAttempt {
+			span.SetTag(TagTransparentRetry, true)
+		}
+	case *stats.InPayload:
+		s.messagesReceived.Add(1)
+		s.bytesReceived.Add(int64(rs.Length))
+	case *stats.OutPayload:
+		s.messagesSent.Add(1)
+		s.bytesSent.Add(int64(rs.Length))
+	case *stats.InHeader:
+		if !h.server {
+			span.SetTag(TagHeaderDuration, s.sinceBegin())
+		}
+	case *stats.OutHeader:
+		if h.server {
+			span.SetTag(TagHeaderDuration, s.sinceBegin())
+		}
+	case *stats.InTrailer:
+		if !h.server {
+			span.SetTag(TagTrailerDuration, s.sinceBegin())
+		}
+	case *stats.OutTrailer:
+		if h.server {
+			span.SetTag(TagTrailerDuration, s.sinceBegin())
+		}
+	case *stats.End:
+		span.SetTag(TagMessagesSent, s.messagesSent.Load())
+		span.SetTag(TagMessagesReceived, s.messagesReceived.Load())
+		span.SetTag(TagBytesSent, s.bytesSent.Load())
+		span.SetTag(TagBytesReceived, s.bytesReceived.Load())
+	}
+	h.handler.HandleRPC(ctx, rs)
+}
+
+func (h *statsHandler) TagConn(ctx context.Context, info *stats.ConnTagInfo) context.Context {
+	return h.handler.TagConn(ctx, info)
+}
+
+func (h *statsHandler) HandleConn(ctx context.Context, cs stats.ConnStats) {
+	h.handler.HandleConn(ctx, cs)
+}
+
+// sinceBegin returns the time since the start of the RPC in milliseconds.
+func (s *rpcStats) sinceBegin() float64 {
+	return float64(time.Since(s.begin)) / float64(time.Millisecond)
+}
+
+// noOpStatsHandler is used when Datadog is disabled.
+type noOpStatsHandler struct{}
+
+func (noOpStatsHandler) TagRPC(ctx context.Context, _ *stats.RPCTagInfo) context.Context {
+	return ctx
+}
+
+func (noOpStatsHandler) HandleRPC(context.Context, stats.RPCStats) {}
+
+func (noOpStatsHandler) TagConn(ctx context.Context, _ *stats.ConnTagInfo) context.Context {
+	return ctx
+}
+
+func (noOpStatsHandler) HandleConn(context.Context, stats.ConnStats) {}
@@ -0,0 +1,127 @@
+package grpc_test
+
+import (
+	"context"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/DataDog/dd-trace-go/v2/ddtrace/ext"
+	"github.com/DataDog/dd-trace-go/v2/ddtrace/mocktracer"
+	"github.com/DataDog/dd-trace-go/v2/ddtrace/tracer"
+	"github.com/coopnorge/go-datadog-lib/v2/internal"
+	"github.com/coopnorge/go-datadog-lib/v2/internal/testhelpers"
+	datadogMiddleware "github.com/coopnorge/go-datadog-lib/v2/middleware/grpc"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	testgrpc "google.golang.org/grpc/interop/grpc_testing"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+func newStatsHandlerClient(t *testing.T, server testgrpc.TestServiceServer, ddOpts ...datadogMiddleware.Option) testgrpc.TestServiceClient {
+	listener := bufconn.Listen(bufSize)
+	grpcServer := grpc.NewServer(grpc.StatsHandler(datadogMiddleware.NewServerHandler(ddOpts...)))
+	testgrpc.RegisterTestServiceServer(grpcServer, server)
+	go func() {
+		_ = grpcServer.Serve(listener)
+	}()
+	t.Cleanup(grpcServer.Stop)
+
+	conn, err := grpc.NewClient("dns:///localhost",
+		grpc.WithContextDialer(func(_ context.Context, _ string) (net.Conn, error) { return listener.Dial() }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithStatsHandler(datadogMiddleware.NewClientHandler(ddOpts...)),
+	)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = conn.Close() })
+	return testgrpc.NewTestServiceClient(conn)
+}
+
+func TestStatsHandlers(t *testing.T) {
+	testhelpers.ConfigureDatadog(t)
+	testTracer := mocktracer.Start()
+	t.Cleanup(testTracer.Stop)
+
+	server := &testServer{}
+	client := newStatsHandlerClient(t, server,
+		datadogMiddleware.WithServiceName("my-service"),
+		datadogMiddleware.WithCustomTag("team", "checkout"),
+		datadogMiddleware.WithMetadataTags(map[string]string{"x-tenant": "tenant"}),
+	)
+
+	ctx := metadata.AppendToOutgoingContext(context.Background(), "x-tenant", "coop-norge")
+	_, err := client.EmptyCall(ctx, &testgrpc.Empty{})
+	require.NoError(t, err)
+
+	// The server span is finished after the response is sent.
+	assert.Eventually(t, func() bool { return len(testTracer.FinishedSpans()) == 2 }, time.Second, 10*time.Millisecond)
+	spans := testTracer.FinishedSpans()
+	require.Len(t, spans, 2)
+	for _, span := range spans {
+		assert.Equal(t, server.ddTraceID, span.TraceID())
+		assert.Equal(t, "my-service", span.Tag(ext.ServiceName))
+		assert.Equal(t, "checkout", span.Tag("team"))
+		assert.Equal(t, "coop-norge", span.Tag("tenant"))
+		assert.EqualValues(t, 1, span.Tag(datadogMiddleware.TagMessagesSent))
+		assert.EqualValues(t, 1, span.Tag(datadogMiddleware.TagMessagesReceived))
+		assert.NotNil(t, span.Tag(datadogMiddleware.TagHeaderDuration))
+		assert.NotNil(t, span.Tag(datadogMiddleware.TagTrailerDuration))
+		if span.Tag(ext.SpanKind) == ext.SpanKindServer {
+			assert.Equal(t, server.ddParentID, span.ParentID())
+		}
+	}
+}
+
+func TestStatsHandlersStream(t *testing.T) {
+	testhelpers.ConfigureDatadog(t)
+	testTracer := mocktracer.Start()
+	t.Cleanup(testTracer.Stop)
+
+	client := newStatsHandlerClient(t, &testServer{})
+
+	stream, err := client.StreamingOutputCall(context.Background(), &testgrpc.StreamingOutputCallRequest{})
+	require.NoError(t, err)
+	_, err = stream.Recv()
+	require.ErrorIs(t, err, io.EOF)
+
+	assert.Eventually(t, func() bool { return len(testTracer.FinishedSpans()) == 2 }, time.Second, 10*time.Millisecond)
+	// The request is the only message of the stream.
+	for _, span := range testTracer.FinishedSpans() {
+		if span.Tag(ext.SpanKind) == ext.SpanKindServer {
+			assert.EqualValues(t, 1, span.Tag(datadogMiddleware.TagMessagesReceived))
+			assert.EqualValues(t, 0, span.Tag(datadogMiddleware.TagMessagesSent))
+		} else {
+			assert.EqualValues(t, 1, span.Tag(datadogMiddleware.TagMessagesSent))
+			assert.EqualValues(t, 0, span.Tag(datadogMiddleware.TagMessagesReceived))
+		}
+	}
+}
+
+func TestStatsHandlersUntracedMethods(t *testing.T) {
+	testhelpers.ConfigureDatadog(t)
+	testTracer := mocktracer.Start()
+	t.Cleanup(testTracer.Stop)
+
+	client := newStatsHandlerClient(t, &metricsTestServer{},
+		datadogMiddleware.WithUntracedMethods("/grpc.testing.TestService/*"),
+	)
+
+	span, ctx := tracer.StartSpanFromContext(context.Background(), "caller")
+	_, err := client.UnaryCall(ctx, &testgrpc.SimpleRequest{})
+	require.NoError(t, err)
+
+	assert.Empty(t, testTracer.FinishedSpans(), "the caller's span must not be finished by the handler")
+	span.Finish()
+	assert.Len(t, testTracer.FinishedSpans(), 1)
+}
+
+func TestStatsHandlersDatadogDisabled(t *testing.T) {
+	t.Setenv(internal.DatadogDisable, "true")
+
+	assert.NotNil(t, datadogMiddleware.NewServerHandler())
+	assert.NotNil(t, datadogMiddleware.NewClientHandler())
+}
@@ -0,0 +1,78 @@
+package http //nolint:revive
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/DataDog/dd-trace-go/v2/ddtrace/tracer"
+	"github.com/coopnorge/go-datadog-lib/v2/internal"
+)
+
+// Prefixes of the tags attached by WithRequestHeaderTags,
+// WithResponseHeaderTags and WithQueryParamAllowlist.
+const (
+	requestHeaderTagPrefix  = "http.request.headers."
+	responseHeaderTagPrefix = "http.response.headers."
+	queryParamTagPrefix     = "http.query."
+)
+
+// capturesRequest reports whether any request headers or query parameters
+// should be attached to the span.
+func (cfg *config) capturesRequest() bool {
+	return len(cfg.requestHeaders) > 0 || len(cfg.queryParams) > 0
+}
+
+// captureTags wraps the handler to attach the allowlisted request headers,
+// response headers and query parameters to the span of the request. The
+// handler must be wrapped by the tracing middleware, so that the span is in
+// the request's context.
+func (cfg *config) captureTags(handler http.Handler) http.Handler {
+	if !cfg.capturesRequest() && len(cfg.responseHeaders) == 0 {
+		return handler
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		span, ok := tracer.SpanFromContext(req.Context())
+		if !ok {
+			handler.ServeHTTP(w, req)
+			return
+		}
+		cfg.tagRequest(span, req)
+		handler.ServeHTTP(w, req)
+		cfg.tagResponse(span, w.Header())
+	})
+}
+
+func (cfg *config) tagRequest(span *tracer.Span, req *http.Request) {
+	cfg.tagHeaders(span, requestHeaderTagPrefix, cfg.requestHeaders, req.Header)
+	if len(cfg.queryParams) == 0 {
+		return
+	}
+	query := req.URL.Query()
+	for _, param := range cfg.queryParams {
+		if values, ok := query[param]; ok {
+			span.SetTag(queryParamTagPrefix+param, cfg.tagValue(param, values))
+		}
+	}
+}
+
+func (cfg *config) tagResponse(span *tracer.Span, header http.Header) {
+	cfg.tagHeaders(span, responseHeaderTagPrefix, cfg.responseHeaders, header)
+}
+
+func (cfg *config) tagHeaders(span *tracer.Span, prefix string, names []string, header http.Header) {
+	for _, name := range names {
+		if values := header.Values(name); len(values) > 0 {
+			span.SetTag(prefix+strings.ToLower(name), cfg.tagValue(name, values))
+		}
+	}
+}
+
+// tagValue joins the values, and hashes them if the name is in
+// WithHashedTagValues.
+func (cfg *config) tagValue(name string, values []string) string {
+	value := strings.Join(values, ",")
+	if _, ok := cfg.hashedValues[strings.ToLower(name)]; ok {
+		return internal.HashTagValue(value)
+	}
+	return value
+}
@@ -13,6 +13,7 @@ import (
 	"github.com/DataDog/dd-trace-go/v2/ddtrace/ext"
 	"github.com/DataDog/dd-trace-go/v2/ddtrace/mocktracer"
 	"github.com/DataDog/dd-trace-go/v2/ddtrace/tracer"
+	"github.com/coopnorge/go-datadog-lib/v2/internal"
 	"github.com/coopnorge/go-datadog-lib/v2/internal/testhelpers"
 	datadogMiddleware "github.com/coopnorge/go-datadog-lib/v2/middleware/http"
 	"github.com/stretchr/testify/assert"
@@ -130,3 +131,73 @@ func TestURLIsNotInTags(t *testing.T) {
 		}
 	}
 }
+
+func TestAddTracingToClientWithErrorStatuses(t *testing.T) {
+	testhelpers.ConfigureDatadog(t)
+	testTracer := mocktracer.Start()
+	t.Cleanup(testTracer.Stop)
+
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		code, err := strconv.Atoi(strings.TrimPrefix(r.URL.Path, "/"))
+		require.NoError(t, err)
+		w.WriteHeader(code)
+	}))
+	t.Cleanup(s.Close)
+
+	client := datadogMiddleware.AddTracingToClient(&http.Client{Timeout: 500 * time.Millisecond},
+		datadogMiddleware.WithErrorStatuses(func(status int) bool {
+			return status >= 400 && status != http.StatusNotFound
+		}),
+	)
+
+	for code, wantError := range map[int]bool{200: false, 404: false, 429: true, 500: true} {
+		t.Run(strconv.Itoa(code), func(t *testing.T) {
+			testTracer.Reset()
+			res, err := client.Get(s.URL + "/" + strconv.Itoa(code))
+			require.NoError(t, err)
+			require.NoError(t, res.Body.Close())
+			spans := testTracer.FinishedSpans()
+			require.Len(t, spans, 1)
+			assert.Equal(t, wantError, spans[0].Tag(ext.ErrorMsg) != nil)
+		})
+	}
+}
+
+func TestAddTracingToClientWithHeaderAndQueryTags(t *testing.T) {
+	testhelpers.ConfigureDatadog(t)
+	testTracer := mocktracer.Start()
+	t.Cleanup(testTracer.Stop)
+
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("X-Ratelimit-Remaining", "99")
+		w.WriteHeader(http.StatusOK)
+	}))
+	t.Cleanup(s.Close)
+
+	client := datadogMiddleware.AddTracingToClient(&http.Client{Timeout: 500 * time.Millisecond},
+		datadogMiddleware.WithRequestHeaderTags("X-Client-Version", "X-Tenant"),
+		datadogMiddleware.WithResponseHeaderTags("X-Ratelimit-Remaining"),
+		datadogMiddleware.WithQueryParamAllowlist("customer"),
+		datadogMiddleware.WithHashedTagValues("customer"),
+	)
+
+	req, err := http.NewRequest(http.MethodGet, s.URL+"/orders?customer=12345&email=pii@example.com", nil)
+	require.NoError(t, err)
+	req.Header.Set("X-Client-Version", "1.2.3")
+	res, err := client.Do(req)
+	require.NoError(t, err)
+	require.NoError(t, res.Body.Close())
+
+	spans := testTracer.FinishedSpans()
+	require.Len(t, spans, 1)
+	span := spans[0]
+	assert.Equal(t, "1.2.3", span.Tag("http.request.headers.x-client-version"))
+	assert.Nil(t, span.Tag("http.request.headers.x-tenant"))
+	assert.Equal(t, "99", span.Tag("http.response.headers.x-ratelimit-remaining"))
+	assert.Equal(t, internal.HashTagValue("12345"), span.Tag("http.query.customer"))
+	for tag, tagValue := range span.Tags() {
+		if str, ok := tagValue.(string); ok {
+			assert.NotContains(t, str, "pii", "Tag %q contains a query parameter that is not allowlisted", tag)
+		}
+	}
+}
@@ -31,3 +31,27 @@ func ExampleAddTracingToClient() {
 	}
 	println(resp)
 }
+
+func ExampleNewServeMux() {
+	mux := datadogMiddleware.NewServeMux(datadogMiddleware.WithCustomTag("team", "checkout"))
+	mux.HandleFunc("GET /orders/{id}", func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	server := &http.Server{
+		Addr:              ":8080",
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	_ = server.ListenAndServe()
+}
+
+func ExampleTemplatedPathResourceNamer() {
+	client := datadogMiddleware.AddTracingToClient(&http.Client{Timeout: 10 * time.Second},
+		// Requests to e.g. https://example.com/orders/12345 are named "GET https://example.com/orders/{id}".
+		datadogMiddleware.WithResourceNamer(datadogMiddleware.TemplatedPathResourceNamer(
+			datadogMiddleware.WithRouteTable("/products/{sku}"),
+		)),
+	)
+	println(client)
+}
@@ -0,0 +1,116 @@
+package http //nolint:revive
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/coopnorge/go-datadog-lib/v2/internal"
+	"github.com/coopnorge/go-datadog-lib/v2/metrics"
+)
+
+// Names of the metrics sent by AddMetricsToClient.
+const (
+	MetricClientRequests = "http.client.requests"
+	MetricClientErrors   = "http.client.errors"
+	MetricClientDuration = "http.client.duration"
+)
+
+// maxTagLength is the maximum length of a tag, including the key.
+const maxTagLength = 200
+
+// AddMetricsToClient wraps the net/http.Client to send metrics for every
+// request: the number of requests, the number of failed requests and the
+// duration of each request in milliseconds, as a distribution. The metrics are
+// tagged with the method, host and status class, such as "2xx". If
+// WithResourceNamer is used, they are also tagged with the ResourceName, so
+// pass a ResourceNamer with bounded values, such as
+// TemplatedPathResourceNamer. Requests that fail without a response have the
+// status class "error". Responses with a 5xx status are counted as failed,
+// unless WithErrorStatuses is used.
+//
+// AddMetricsToClient can be combined with AddTracingToClient, and the same
+// options should be passed to both.
+func AddMetricsToClient(client *http.Client, options ...Option) *http.Client {
+	if internal.IsDatadogDisabled() {
+		return client
+	}
+	cfg := defaults()
+	// Unlike spans, metrics are only tagged with the resource when a
+	// ResourceNamer is passed, as the default one includes the full path,
+	// which might contain user-ids and has unbounded values.
+	cfg.resourceNamer = nil
+	for _, opt := range options {
+		opt(cfg)
+	}
+	base := client.Transport
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	client.Transport = &metricsRoundTripper{base: base, cfg: cfg}
+	return client
+}
+
+type metricsRoundTripper struct {
+	base http.RoundTripper
+	cfg  *config
+}
+
+func (rt *metricsRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	if rt.cfg.requestIgnorer != nil && rt.cfg.requestIgnorer(req) {
+		return rt.base.RoundTrip(req)
+	}
+
+	start := time.Now()
+	res, err := rt.base.RoundTrip(req)
+	elapsed := time.Since(start)
+
+	statusClass := "error"
+	failed := err != nil
+	if res != nil {
+		statusClass = strconv.Itoa(res.StatusCode/100) + "xx"
+		failed = failed || rt.isErrorStatus(res.StatusCode)
+	}
+
+	opts := []metrics.Option{
+		metrics.WithTag("method", req.Method),
+		metrics.WithTag("status_class", statusClass),
+	}
+	if req.URL.Host != "" {
+		opts = append(opts, metrics.WithTag("host", req.URL.Host))
+	}
+	if rt.cfg.resourceNamer != nil {
+		if resource := rt.cfg.resourceNamer(req); resource != "" {
+			opts = append(opts, metrics.WithTag("resource", truncateTagValue("resource", resource)))
+		}
+	}
+
+	ctx := req.Context()
+	metrics.IncrCtx(ctx, MetricClientRequests, opts...)
+	if failed {
+		metrics.IncrCtx(ctx, MetricClientErrors, opts...)
+	}
+	metrics.DistributionCtx(ctx, MetricClientDuration, float64(elapsed)/float64(time.Millisecond), opts...)
+
+	return res, err
+}
+
+// isErrorStatus reports whether the response status should be counted as an
+// error. Unless WithErrorStatuses is used, 5xx responses are counted as
+// errors.
+func (rt *metricsRoundTripper) isErrorStatus(status int) bool {
+	if rt.cfg.isErrorStatus != nil {
+		return rt.cfg.isErrorStatus(status)
+	}
+	return status >= http.StatusInternalServerError
+}
+
+// truncateTagValue truncates value, so that the tag does not exceed the
+// maximum length of a tag.
+func truncateTagValue(key, value string) string {
+	maxLength := maxTagLength - len(key) - 1
+	if len(value) > maxLength {
+		return value[:maxLength]
+	}
+	return value
+}
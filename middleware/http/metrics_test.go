@@ -0,0 +1,108 @@
+package http_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/coopnorge/go-datadog-lib/v2/internal/testhelpers"
+	"github.com/coopnorge/go-datadog-lib/v2/metrics/metricstest"
+	datadogMiddleware "github.com/coopnorge/go-datadog-lib/v2/middleware/http"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAddMetricsToClient(t *testing.T) {
+	testhelpers.ConfigureDatadog(t)
+	recorder := metricstest.Start(t)
+
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/fail":
+			w.WriteHeader(http.StatusBadGateway)
+		case "/missing":
+			w.WriteHeader(http.StatusNotFound)
+		default:
+			w.WriteHeader(http.StatusOK)
+		}
+	}))
+	t.Cleanup(s.Close)
+	host := strings.TrimPrefix(s.URL, "http://")
+
+	client := datadogMiddleware.AddMetricsToClient(&http.Client{Timeout: 500 * time.Millisecond},
+		datadogMiddleware.WithResourceNamer(datadogMiddleware.TemplatedPathResourceNamer()),
+		datadogMiddleware.WithRequestIgnorer(func(req *http.Request) bool { return req.URL.Path == "/ignored" }),
+	)
+
+	for _, path := range []string{"/orders/1", "/orders/2", "/missing", "/fail", "/ignored"} {
+		res, err := client.Get(s.URL + path)
+		require.NoError(t, err)
+		require.NoError(t, res.Body.Close())
+	}
+
+	recorder.AssertCount(t, datadogMiddleware.MetricClientRequests, 2,
+		"method:GET", "host:"+host, "status_class:2xx", "resource:GET http://"+host+"/orders/{id}")
+	recorder.AssertCount(t, datadogMiddleware.MetricClientRequests, 1, "status_class:4xx")
+	recorder.AssertCount(t, datadogMiddleware.MetricClientRequests, 1, "status_class:5xx")
+	recorder.AssertCount(t, datadogMiddleware.MetricClientRequests, 4)
+	recorder.AssertCount(t, datadogMiddleware.MetricClientErrors, 1, "status_class:5xx")
+	recorder.AssertCount(t, datadogMiddleware.MetricClientErrors, 1)
+	assert.Len(t, recorder.Find(datadogMiddleware.MetricClientDuration), 4)
+	recorder.AssertNotEmitted(t, datadogMiddleware.MetricClientRequests, "resource:GET http://"+host+"/ignored")
+}
+
+func TestAddMetricsToClientTransportError(t *testing.T) {
+	testhelpers.ConfigureDatadog(t)
+	recorder := metricstest.Start(t)
+
+	s := httptest.NewServer(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))
+	url := s.URL
+	s.Close()
+
+	client := datadogMiddleware.AddMetricsToClient(&http.Client{Timeout: 500 * time.Millisecond})
+	_, err := client.Get(url) //nolint:bodyclose
+	require.Error(t, err)
+
+	recorder.AssertCount(t, datadogMiddleware.MetricClientRequests, 1, "status_class:error")
+	recorder.AssertCount(t, datadogMiddleware.MetricClientErrors, 1, "status_class:error")
+}
+
+func TestAddMetricsToClientWithErrorStatuses(t *testing.T) {
+	testhelpers.ConfigureDatadog(t)
+	recorder := metricstest.Start(t)
+
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	t.Cleanup(s.Close)
+
+	client := datadogMiddleware.AddMetricsToClient(&http.Client{Timeout: 500 * time.Millisecond},
+		datadogMiddleware.WithErrorStatuses(func(status int) bool { return status == http.StatusTooManyRequests }),
+	)
+	res, err := client.Get(s.URL)
+	require.NoError(t, err)
+	require.NoError(t, res.Body.Close())
+
+	recorder.AssertCount(t, datadogMiddleware.MetricClientErrors, 1, "status_class:4xx")
+}
+
+func TestAddMetricsToClientDefaultOmitsResource(t *testing.T) {
+	testhelpers.ConfigureDatadog(t)
+	recorder := metricstest.Start(t)
+
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	t.Cleanup(s.Close)
+
+	client := datadogMiddleware.AddMetricsToClient(&http.Client{Timeout: 500 * time.Millisecond})
+	res, err := client.Get(s.URL + "/users/12345")
+	require.NoError(t, err)
+	require.NoError(t, res.Body.Close())
+
+	m := recorder.RequireMetric(t, datadogMiddleware.MetricClientRequests, "status_class:2xx")
+	_, ok := m.Tag("resource")
+	assert.False(t, ok, "the path should not be a tag unless a ResourceNamer is passed")
+}
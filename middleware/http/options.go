@@ -3,6 +3,7 @@ package http //nolint:revive
 import (
 	"net/http"
 	"os"
+	"strings"
 
 	httptrace "github.com/DataDog/dd-trace-go/contrib/net/http/v2"
 	"github.com/DataDog/dd-trace-go/v2/ddtrace/ext"
@@ -23,6 +24,13 @@ type config struct {
 	requestIgnorer RequestIgnorer
 	tags           map[string]any
 	removeHTTPURL  bool
+	isErrorStatus  func(status int) bool
+
+	requestHeaders  []string
+	responseHeaders []string
+	queryParams     []string
+	hashedValues    map[string]struct{}
+	panicRecovery   bool
 }
 
 func defaults() *config {
@@ -50,7 +58,7 @@ func convertClientOptions(options ...Option) []httptrace.RoundTripperOption {
 		}
 		cfg.tags[ext.HTTPURL] = "" // dd-trace-go adds the full URL into this tag, but the URL might contain PII in path-parameters or query-parameters, so we simply remove it. See: https://github.com/coopnorge/go-datadog-lib/issues/495
 	}
-	opts := make([]httptrace.RoundTripperOption, 0, 3+len(cfg.tags))
+	opts := make([]httptrace.RoundTripperOption, 0, 6+len(cfg.tags))
 	if cfg.serviceName != "" {
 		opts = append(opts, httptrace.WithService(cfg.serviceName)) // for server use
 	}
@@ -60,6 +68,54 @@ func convertClientOptions(options ...Option) []httptrace.RoundTripperOption {
 	if cfg.requestIgnorer != nil {
 		opts = append(opts, httptrace.WithIgnoreRequest(cfg.requestIgnorer))
 	}
+	if cfg.isErrorStatus != nil {
+		opts = append(opts, httptrace.WithStatusCheck(cfg.isErrorStatus))
+	}
+	opts = append(opts, httptrace.WithBefore(func(req *http.Request, span *tracer.Span) {
+		cfg.tagRequest(span, req)
+		tagAttempt(req.Context(), span)
+	}))
+	if len(cfg.responseHeaders) > 0 {
+		opts = append(opts, httptrace.WithAfter(func(res *http.Response, span *tracer.Span) {
+			if res != nil {
+				cfg.tagResponse(span, res.Header)
+			}
+		}))
+	}
+	for k, v := range cfg.tags {
+		opts = append(opts, httptrace.WithSpanOptions(tracer.Tag(k, v)))
+	}
+	return opts
+}
+
+// convertServerOptions converts the Options to httptrace-typed options for
+// servers. Unlike clients, servers name resources from the matched route
+// pattern by default.
+func convertServerOptions(options ...Option) []httptrace.Option {
+	cfg := defaults()
+	cfg.resourceNamer = PatternResourceNamer()
+	for _, opt := range options {
+		opt(cfg)
+	}
+	if cfg.removeHTTPURL {
+		if cfg.tags == nil {
+			cfg.tags = make(map[string]any)
+		}
+		cfg.tags[ext.HTTPURL] = "" // See convertClientOptions.
+	}
+	opts := make([]httptrace.Option, 0, 4+len(cfg.tags))
+	if cfg.serviceName != "" {
+		opts = append(opts, httptrace.WithService(cfg.serviceName))
+	}
+	if cfg.resourceNamer != nil {
+		opts = append(opts, httptrace.WithResourceNamer(cfg.resourceNamer))
+	}
+	if cfg.requestIgnorer != nil {
+		opts = append(opts, httptrace.WithIgnoreRequest(cfg.requestIgnorer))
+	}
+	if cfg.isErrorStatus != nil {
+		opts = append(opts, httptrace.WithStatusCheck(cfg.isErrorStatus))
+	}
 	for k, v := range cfg.tags {
 		opts = append(opts, httptrace.WithSpanOptions(tracer.Tag(k, v)))
 	}
@@ -103,6 +159,74 @@ func WithCustomTag(key string, value any) Option {
 	}
 }
 
+// WithErrorStatuses specifies a function that will be called with the status
+// code of every response, to determine if the span should be marked as an
+// error. For example, a 404 from a lookup API can be treated as a success, or a
+// 429 as an error. By default, clients mark 4xx responses as errors, and
+// servers mark 5xx responses as errors. The function also determines which
+// responses are counted as errors by AddMetricsToClient.
+func WithErrorStatuses(isErrorStatus func(status int) bool) Option {
+	return func(cfg *config) {
+		cfg.isErrorStatus = isErrorStatus
+	}
+}
+
+// WithRequestHeaderTags will attach the values of the request headers to the
+// span, tagged by "http.request.headers.<header>". Headers that are not in
+// the list are never attached, and headers missing from a request are skipped.
+func WithRequestHeaderTags(headers ...string) Option {
+	return func(cfg *config) {
+		cfg.requestHeaders = append(cfg.requestHeaders, headers...)
+	}
+}
+
+// WithResponseHeaderTags will attach the values of the response headers to
+// the span, tagged by "http.response.headers.<header>". Headers that are not in
+// the list are never attached, and headers missing from a response are
+// skipped.
+func WithResponseHeaderTags(headers ...string) Option {
+	return func(cfg *config) {
+		cfg.responseHeaders = append(cfg.responseHeaders, headers...)
+	}
+}
+
+// WithQueryParamAllowlist will attach the values of the query parameters to
+// the span, tagged by "http.query.<param>". Since the URL is removed from the
+// span, query parameters that are not in the list are never attached.
+func WithQueryParamAllowlist(params ...string) Option {
+	return func(cfg *config) {
+		cfg.queryParams = append(cfg.queryParams, params...)
+	}
+}
+
+// WithHashedTagValues specifies the headers and query parameters, among the
+// ones attached with WithRequestHeaderTags, WithResponseHeaderTags and
+// WithQueryParamAllowlist, whose values are hashed before they are attached.
+// Hashed values can be used to correlate requests, such as all the requests of
+// one user, without exposing the values. Names are case-insensitive.
+func WithHashedTagValues(names ...string) Option {
+	return func(cfg *config) {
+		if cfg.hashedValues == nil {
+			cfg.hashedValues = make(map[string]struct{})
+		}
+		for _, name := range names {
+			cfg.hashedValues[strings.ToLower(name)] = struct{}{}
+		}
+	}
+}
+
+// WithPanicRecovery recovers panics in the handler, and responds with 500
+// Internal Server Error instead. The span of the request is marked as an
+// error with the stack trace of the panic, the metric "panics" is
+// incremented, tagged with the route, and the panic is logged with the trace
+// of the request. Unlike the other options, panics are recovered even if
+// Datadog is disabled. Only valid for servers.
+func WithPanicRecovery() Option {
+	return func(cfg *config) {
+		cfg.panicRecovery = true
+	}
+}
+
 // StaticResourceNamer will set every span's ResourceName to str.
 func StaticResourceNamer(str string) ResourceNamer {
 	return func(_ *http.Request) string {
@@ -127,6 +251,25 @@ func FullURLResourceNamer() ResourceNamer {
 	}
 }
 
+// PatternResourceNamer will name the ResourceName to "GET /orders/{id}", from
+// the pattern of the http.ServeMux route that matched the request. Requests
+// that did not match a route are named by the method only, so that the raw
+// path, which might contain user-ids, is never used. This is the default for
+// servers.
+func PatternResourceNamer() ResourceNamer {
+	return func(req *http.Request) string {
+		if req.Pattern == "" {
+			return req.Method
+		}
+		route := req.Pattern
+		if _, path, ok := strings.Cut(route, " "); ok {
+			// The method is part of the pattern, e.g. "GET /orders/{id}".
+			route = strings.TrimSpace(path)
+		}
+		return req.Method + " " + route
+	}
+}
+
 // HostResourceNamer will name the ResourceName to "www.coop.no"
 func HostResourceNamer() ResourceNamer {
 	return func(req *http.Request) string {
@@ -91,3 +91,25 @@ func TestCustomResourceNamer(t *testing.T) {
 	require.NoError(t, err)
 	require.Equal(t, "GET https://www.coop.no/api/some-service/customers/:customerid", rn(req))
 }
+
+func TestPatternResourceNamer(t *testing.T) {
+	rn := datadogMiddleware.PatternResourceNamer()
+
+	testCases := []struct {
+		pattern  string
+		expected string
+	}{
+		{"", "GET"},
+		{"/orders/{id}", "GET /orders/{id}"},
+		{"GET /orders/{id}", "GET /orders/{id}"},
+		{"GET www.coop.no/orders/{id}", "GET www.coop.no/orders/{id}"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.pattern, func(t *testing.T) {
+			req, err := http.NewRequest("GET", "https://www.coop.no/orders/1234", nil)
+			require.NoError(t, err)
+			req.Pattern = tc.pattern
+			require.Equal(t, tc.expected, rn(req))
+		})
+	}
+}
@@ -0,0 +1,38 @@
+package http //nolint:revive
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/coopnorge/go-datadog-lib/v2/internal/recovery"
+)
+
+// MetricPanics is the name of the metric counting the panics recovered by
+// WithPanicRecovery.
+const MetricPanics = recovery.MetricPanics
+
+// recoverPanics wraps the handler to recover panics, if WithPanicRecovery is
+// used. The handler must be wrapped by the tracing middleware, so that the
+// span is in the request's context.
+func (cfg *config) recoverPanics(handler http.Handler) http.Handler {
+	if !cfg.panicRecovery {
+		return handler
+	}
+	resourceNamer := PatternResourceNamer()
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		defer func() {
+			r := recover()
+			if r == nil {
+				return
+			}
+			if abortErr, ok := r.(error); ok && errors.Is(abortErr, http.ErrAbortHandler) {
+				// http.ErrAbortHandler is used to abort a response, and is
+				// not an error.
+				panic(r)
+			}
+			_ = recovery.Record(req.Context(), "http", resourceNamer(req), r)
+			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		}()
+		handler.ServeHTTP(w, req)
+	})
+}
@@ -0,0 +1,59 @@
+package http_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/DataDog/dd-trace-go/v2/ddtrace/ext"
+	"github.com/DataDog/dd-trace-go/v2/ddtrace/mocktracer"
+	"github.com/coopnorge/go-datadog-lib/v2/internal"
+	"github.com/coopnorge/go-datadog-lib/v2/internal/testhelpers"
+	"github.com/coopnorge/go-datadog-lib/v2/metrics/metricstest"
+	datadogMiddleware "github.com/coopnorge/go-datadog-lib/v2/middleware/http"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithPanicRecovery(t *testing.T) {
+	testhelpers.ConfigureDatadog(t)
+	testTracer := mocktracer.Start()
+	t.Cleanup(testTracer.Stop)
+	recorder := metricstest.Start(t)
+
+	mux := datadogMiddleware.NewServeMux(datadogMiddleware.WithPanicRecovery())
+	mux.HandleFunc("GET /orders/{id}", func(http.ResponseWriter, *http.Request) {
+		panic("boom")
+	})
+	mux.HandleFunc("GET /abort", func(http.ResponseWriter, *http.Request) {
+		panic(http.ErrAbortHandler)
+	})
+
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/orders/12345", nil))
+	assert.Equal(t, http.StatusInternalServerError, rec.Code)
+	assert.NotContains(t, rec.Body.String(), "boom")
+
+	spans := testTracer.FinishedSpans()
+	require.Len(t, spans, 1)
+	assert.Equal(t, "500", spans[0].Tag(ext.HTTPCode))
+	assert.NotNil(t, spans[0].Tag(ext.ErrorMsg))
+	assert.Contains(t, spans[0].Tag(ext.ErrorStack), "panic: boom")
+	recorder.AssertCount(t, datadogMiddleware.MetricPanics, 1, "component:http", "resource:GET /orders/{id}")
+
+	assert.PanicsWithValue(t, http.ErrAbortHandler, func() {
+		mux.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/abort", nil))
+	})
+}
+
+func TestWithPanicRecoveryDatadogDisabled(t *testing.T) {
+	t.Setenv(internal.DatadogDisable, "true")
+
+	handler := datadogMiddleware.WrapHandler(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {
+		panic("boom")
+	}), datadogMiddleware.WithPanicRecovery())
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Equal(t, http.StatusInternalServerError, rec.Code)
+}
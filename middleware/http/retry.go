@@ -0,0 +1,316 @@
+package http //nolint:revive
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/DataDog/dd-trace-go/v2/ddtrace/ext"
+	"github.com/DataDog/dd-trace-go/v2/ddtrace/tracer"
+	"github.com/coopnorge/go-datadog-lib/v2/internal"
+)
+
+// Tags attached by AddRetriesToClient.
+const (
+	// TagRetryAttempt is the number of the attempt, starting at 1, attached to
+	// the span of every attempt.
+	TagRetryAttempt = "retry.attempt"
+	// TagRetryBackoff is the delay in milliseconds before the attempt, attached
+	// to the span of every attempt.
+	TagRetryBackoff = "retry.backoff_ms"
+	// TagRetryAttempts is the number of attempts that were made, attached to
+	// the span of the logical request.
+	TagRetryAttempts = "retry.attempts"
+	// TagRetryOutcome is the outcome of the logical request, attached to its
+	// span. See the RetryOutcome constants.
+	TagRetryOutcome = "retry.outcome"
+)
+
+// Values of TagRetryOutcome.
+const (
+	// RetryOutcomeSuccess is the outcome of a request whose last attempt
+	// succeeded.
+	RetryOutcomeSuccess = "success"
+	// RetryOutcomeNotRetryable is the outcome of a request whose last attempt
+	// failed, but could not be retried.
+	RetryOutcomeNotRetryable = "not_retryable"
+	// RetryOutcomeExhausted is the outcome of a request whose attempts all
+	// failed.
+	RetryOutcomeExhausted = "exhausted"
+	// RetryOutcomeCanceled is the outcome of a request whose context was done
+	// while waiting to retry.
+	RetryOutcomeCanceled = "canceled"
+)
+
+// retrySpanName is the operation name of the span of the logical request.
+const retrySpanName = "http.retry"
+
+// RetryPolicy determines which requests AddRetriesToClient retries, and how
+// often. The zero value retries failed requests up to two times.
+type RetryPolicy struct {
+	// MaxAttempts is the maximum number of attempts, including the first.
+	// Defaults to 3.
+	MaxAttempts int
+	// Backoff returns the delay before the attempt, where attempt 2 is the
+	// first retry. Defaults to an exponential backoff, starting at 100
+	// milliseconds, and capped at 2 seconds, of which a random delay between
+	// half and all of it is used.
+	Backoff func(attempt int) time.Duration
+	// RetryStatus reports whether a response with the status code should be
+	// retried. Defaults to 429, 502, 503 and 504.
+	RetryStatus func(status int) bool
+	// RetryError reports whether a request that failed without a response
+	// should be retried. Defaults to every error, except when the request's
+	// context is done.
+	RetryError func(err error) bool
+}
+
+func (p RetryPolicy) withDefaults() RetryPolicy {
+	if p.MaxAttempts <= 0 {
+		p.MaxAttempts = 3
+	}
+	if p.Backoff == nil {
+		p.Backoff = defaultBackoff
+	}
+	if p.RetryStatus == nil {
+		p.RetryStatus = defaultRetryStatus
+	}
+	if p.RetryError == nil {
+		p.RetryError = defaultRetryError
+	}
+	return p
+}
+
+func defaultBackoff(attempt int) time.Duration {
+	const base, maxBackoff = 100 * time.Millisecond, 2 * time.Second
+	backoff := min(base<<(attempt-2), maxBackoff)
+	// Equal jitter, so that clients that failed together do not retry
+	// together, while still waiting at least half the backoff.
+	return backoff/2 + rand.N(backoff/2+1) //nolint:gosec
+}
+
+func defaultRetryStatus(status int) bool {
+	switch status {
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+func defaultRetryError(err error) bool {
+	return !errors.Is(err, context.Canceled) && !errors.Is(err, context.DeadlineExceeded)
+}
+
+// AddRetriesToClient wraps the net/http.Client to retry failed requests
+// according to the policy. When tracing is enabled, a span is created for
+// every logical request, tagged with the number of attempts and the outcome,
+// and the attempts are its children. Call AddRetriesToClient after
+// AddTracingToClient, with the same options, so that every attempt gets its
+// own span, tagged with the attempt number and the backoff delay before it.
+//
+// Only idempotent requests are retried, as defined by net/http: requests with
+// the methods GET, HEAD, OPTIONS, TRACE, PUT and DELETE, or with an
+// "Idempotency-Key" header. Requests with a body are only retried if
+// Request.GetBody is set, as it is by http.NewRequest.
+func AddRetriesToClient(client *http.Client, policy RetryPolicy, options ...Option) *http.Client {
+	cfg := defaults()
+	for _, opt := range options {
+		opt(cfg)
+	}
+	base := client.Transport
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	client.Transport = &retryRoundTripper{
+		base:   base,
+		cfg:    cfg,
+		policy: policy.withDefaults(),
+		traced: !internal.IsDatadogDisabled(),
+	}
+	return client
+}
+
+type retryRoundTripper struct {
+	base   http.RoundTripper
+	cfg    *config
+	policy RetryPolicy
+	traced bool
+}
+
+// attemptKey is the context key of the attempt that a request is made for.
+type attemptKey struct{}
+
+// attemptInfo is the attempt that a request is made for, stored in the
+// request's context by AddRetriesToClient, and attached to the attempt's span
+// by AddTracingToClient.
+type attemptInfo struct {
+	attempt int
+	backoff time.Duration
+}
+
+// tagAttempt attaches the attempt in the context, if any, to the span.
+func tagAttempt(ctx context.Context, span *tracer.Span) {
+	if a, ok := ctx.Value(attemptKey{}).(attemptInfo); ok {
+		span.SetTag(TagRetryAttempt, a.attempt)
+		span.SetTag(TagRetryBackoff, float64(a.backoff)/float64(time.Millisecond))
+	}
+}
+
+func (rt *retryRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+	var span *tracer.Span
+	if rt.traced && (rt.cfg.requestIgnorer == nil || !rt.cfg.requestIgnorer(req)) {
+		span, ctx = tracer.StartSpanFromContext(ctx, retrySpanName, rt.spanOptions(req)...)
+	}
+
+	var (
+		res     *http.Response
+		err     error
+		outcome string
+		attempt int
+		backoff time.Duration
+	)
+	for attempt = 1; ; attempt++ {
+		attemptReq, reqErr := rt.attemptRequest(ctx, req, attempt, backoff)
+		if reqErr != nil {
+			res, err = nil, reqErr
+			attempt--
+			outcome = RetryOutcomeNotRetryable
+			break
+		}
+		res, err = rt.base.RoundTrip(attemptReq)
+		if !rt.shouldRetry(req, res, err) {
+			outcome = RetryOutcomeSuccess
+			if err != nil || rt.isErrorStatus(res.StatusCode) {
+				outcome = RetryOutcomeNotRetryable
+			}
+			break
+		}
+		if attempt == rt.policy.MaxAttempts {
+			outcome = RetryOutcomeExhausted
+			break
+		}
+
+		backoff = rt.policy.Backoff(attempt + 1)
+		if !sleep(ctx, backoff) {
+			outcome = RetryOutcomeCanceled
+			break
+		}
+		if res != nil {
+			drainAndClose(res.Body)
+		}
+	}
+
+	if span != nil {
+		rt.finishSpan(span, attempt, outcome, res, err)
+	}
+	return res, err
+}
+
+// attemptRequest returns the request to send for the attempt.
+func (rt *retryRoundTripper) attemptRequest(ctx context.Context, req *http.Request, attempt int, backoff time.Duration) (*http.Request, error) {
+	attemptReq := req.Clone(context.WithValue(ctx, attemptKey{}, attemptInfo{attempt: attempt, backoff: backoff}))
+	if attempt > 1 && req.Body != nil && req.Body != http.NoBody {
+		body, err := req.GetBody()
+		if err != nil {
+			return nil, err
+		}
+		attemptReq.Body = body
+	}
+	return attemptReq, nil
+}
+
+func (rt *retryRoundTripper) shouldRetry(req *http.Request, res *http.Response, err error) bool {
+	if req.Context().Err() != nil || !isIdempotent(req) {
+		return false
+	}
+	if req.Body != nil && req.Body != http.NoBody && req.GetBody == nil {
+		return false
+	}
+	if err != nil {
+		return rt.policy.RetryError(err)
+	}
+	return rt.policy.RetryStatus(res.StatusCode)
+}
+
+// isErrorStatus reports whether the response status marks the logical
+// request as an error. Unless WithErrorStatuses is used, 4xx and 5xx
+// responses are errors, like for the spans of AddTracingToClient.
+func (rt *retryRoundTripper) isErrorStatus(status int) bool {
+	if rt.cfg.isErrorStatus != nil {
+		return rt.cfg.isErrorStatus(status)
+	}
+	return status >= http.StatusBadRequest
+}
+
+func (rt *retryRoundTripper) spanOptions(req *http.Request) []tracer.StartSpanOption {
+	opts := []tracer.StartSpanOption{
+		tracer.SpanType(ext.SpanTypeHTTP),
+		tracer.Tag(ext.HTTPMethod, req.Method),
+		tracer.Tag(ext.SpanKind, ext.SpanKindClient),
+		tracer.Tag(ext.NetworkDestinationName, req.URL.Hostname()),
+	}
+	if rt.cfg.serviceName != "" {
+		opts = append(opts, tracer.ServiceName(rt.cfg.serviceName))
+	}
+	if rt.cfg.resourceNamer != nil {
+		opts = append(opts, tracer.ResourceName(rt.cfg.resourceNamer(req)))
+	}
+	for k, v := range rt.cfg.tags {
+		opts = append(opts, tracer.Tag(k, v))
+	}
+	return opts
+}
+
+func (rt *retryRoundTripper) finishSpan(span *tracer.Span, attempts int, outcome string, res *http.Response, err error) {
+	span.SetTag(TagRetryAttempts, attempts)
+	span.SetTag(TagRetryOutcome, outcome)
+	if err != nil {
+		span.Finish(tracer.WithError(err))
+		return
+	}
+	span.SetTag(ext.HTTPCode, strconv.Itoa(res.StatusCode))
+	if rt.isErrorStatus(res.StatusCode) {
+		span.SetTag(ext.ErrorNoStackTrace, fmt.Errorf("%d: %s", res.StatusCode, http.StatusText(res.StatusCode)))
+	}
+	span.Finish()
+}
+
+// isIdempotent reports whether the request can be retried, using the same
+// rules as net/http.
+func isIdempotent(req *http.Request) bool {
+	switch req.Method {
+	case "", http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace, http.MethodPut, http.MethodDelete:
+		return true
+	}
+	_, ok := req.Header["Idempotency-Key"]
+	if !ok {
+		_, ok = req.Header["X-Idempotency-Key"]
+	}
+	return ok
+}
+
+// sleep waits for the duration, and reports whether it did so before the
+// context was done.
+func sleep(ctx context.Context, d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
+
+// drainAndClose reads a bounded part of the body, so that the connection can
+// be reused, and closes it.
+func drainAndClose(body io.ReadCloser) {
+	_, _ = io.Copy(io.Discard, io.LimitReader(body, 4096))
+	_ = body.Close()
+}
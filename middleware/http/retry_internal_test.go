@@ -0,0 +1,29 @@
+package http
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDefaultBackoff(t *testing.T) {
+	tests := []struct {
+		attempt int
+		backoff time.Duration
+	}{
+		{2, 100 * time.Millisecond},
+		{3, 200 * time.Millisecond},
+		{4, 400 * time.Millisecond},
+		{6, 1600 * time.Millisecond},
+		{7, 2 * time.Second},
+		{20, 2 * time.Second},
+	}
+	for _, tc := range tests {
+		for range 100 {
+			got := defaultBackoff(tc.attempt)
+			assert.GreaterOrEqual(t, got, tc.backoff/2, "attempt %d", tc.attempt)
+			assert.LessOrEqual(t, got, tc.backoff, "attempt %d", tc.attempt)
+		}
+	}
+}
@@ -0,0 +1,220 @@
+package http_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/DataDog/dd-trace-go/v2/ddtrace/ext"
+	"github.com/DataDog/dd-trace-go/v2/ddtrace/mocktracer"
+	"github.com/coopnorge/go-datadog-lib/v2/internal"
+	"github.com/coopnorge/go-datadog-lib/v2/internal/testhelpers"
+	datadogMiddleware "github.com/coopnorge/go-datadog-lib/v2/middleware/http"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newFlakyServer returns a server that responds with the statuses in order,
+// and then with 200 OK, and the number of requests it has received.
+func newFlakyServer(t *testing.T, statuses ...int) (*httptest.Server, *atomic.Int32) {
+	var requests atomic.Int32
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+		if r.Method == http.MethodPut {
+			assert.Equal(t, "payload", string(body))
+		}
+		n := int(requests.Add(1))
+		if n <= len(statuses) {
+			w.WriteHeader(statuses[n-1])
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	t.Cleanup(s.Close)
+	return s, &requests
+}
+
+func noBackoff(int) time.Duration { return 0 }
+
+func newRetryingClient(policy datadogMiddleware.RetryPolicy, options ...datadogMiddleware.Option) *http.Client {
+	client := &http.Client{Timeout: 500 * time.Millisecond}
+	client = datadogMiddleware.AddTracingToClient(client, options...)
+	return datadogMiddleware.AddRetriesToClient(client, policy, options...)
+}
+
+func TestAddRetriesToClient(t *testing.T) {
+	testhelpers.ConfigureDatadog(t)
+	testTracer := mocktracer.Start()
+	t.Cleanup(testTracer.Stop)
+
+	s, requests := newFlakyServer(t, http.StatusTooManyRequests, http.StatusBadGateway)
+	client := newRetryingClient(datadogMiddleware.RetryPolicy{
+		Backoff: func(attempt int) time.Duration { return time.Duration(attempt) * time.Millisecond },
+	}, datadogMiddleware.WithResourceNamer(datadogMiddleware.StaticResourceNamer("GET /orders")))
+
+	req, err := http.NewRequest(http.MethodPut, s.URL+"/orders", strings.NewReader("payload"))
+	require.NoError(t, err)
+	res, err := client.Do(req)
+	require.NoError(t, err)
+	require.NoError(t, res.Body.Close())
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	assert.EqualValues(t, 3, requests.Load())
+
+	spans := testTracer.FinishedSpans()
+	require.Len(t, spans, 4)
+	parent := spans[3]
+	assert.Equal(t, "http.retry", parent.OperationName())
+	assert.Equal(t, "GET /orders", parent.Tag(ext.ResourceName))
+	assert.EqualValues(t, 3, parent.Tag(datadogMiddleware.TagRetryAttempts))
+	assert.Equal(t, datadogMiddleware.RetryOutcomeSuccess, parent.Tag(datadogMiddleware.TagRetryOutcome))
+	assert.Nil(t, parent.Tag(ext.ErrorMsg))
+
+	for i, attempt := range spans[:3] {
+		assert.Equal(t, parent.SpanID(), attempt.ParentID())
+		assert.EqualValues(t, i+1, attempt.Tag(datadogMiddleware.TagRetryAttempt))
+	}
+	assert.EqualValues(t, 0, spans[0].Tag(datadogMiddleware.TagRetryBackoff))
+	assert.EqualValues(t, 2, spans[1].Tag(datadogMiddleware.TagRetryBackoff))
+	assert.EqualValues(t, 3, spans[2].Tag(datadogMiddleware.TagRetryBackoff))
+	assert.NotNil(t, spans[0].Tag(ext.ErrorMsg))
+	assert.Nil(t, spans[2].Tag(ext.ErrorMsg))
+}
+
+func TestAddRetriesToClientExhausted(t *testing.T) {
+	testhelpers.ConfigureDatadog(t)
+	testTracer := mocktracer.Start()
+	t.Cleanup(testTracer.Stop)
+
+	s, requests := newFlakyServer(t, http.StatusServiceUnavailable, http.StatusServiceUnavailable, http.StatusServiceUnavailable)
+	client := newRetryingClient(datadogMiddleware.RetryPolicy{MaxAttempts: 2, Backoff: noBackoff})
+
+	res, err := client.Get(s.URL)
+	require.NoError(t, err)
+	require.NoError(t, res.Body.Close())
+	assert.Equal(t, http.StatusServiceUnavailable, res.StatusCode)
+	assert.EqualValues(t, 2, requests.Load())
+
+	spans := testTracer.FinishedSpans()
+	require.Len(t, spans, 3)
+	parent := spans[2]
+	assert.EqualValues(t, 2, parent.Tag(datadogMiddleware.TagRetryAttempts))
+	assert.Equal(t, datadogMiddleware.RetryOutcomeExhausted, parent.Tag(datadogMiddleware.TagRetryOutcome))
+	assert.Equal(t, "503", parent.Tag(ext.HTTPCode))
+	assert.NotNil(t, parent.Tag(ext.ErrorMsg))
+}
+
+func TestAddRetriesToClientNotRetryable(t *testing.T) {
+	testhelpers.ConfigureDatadog(t)
+	testTracer := mocktracer.Start()
+	t.Cleanup(testTracer.Stop)
+
+	tests := []struct {
+		name   string
+		method string
+		status int
+		header http.Header
+		want   int32
+	}{
+		{"Status not retried", http.MethodGet, http.StatusNotFound, nil, 1},
+		{"Not idempotent", http.MethodPost, http.StatusServiceUnavailable, nil, 1},
+		{"Idempotency key", http.MethodPost, http.StatusServiceUnavailable, http.Header{"Idempotency-Key": {"42"}}, 2},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			testTracer.Reset()
+			s, requests := newFlakyServer(t, tc.status)
+			client := newRetryingClient(datadogMiddleware.RetryPolicy{Backoff: noBackoff})
+
+			req, err := http.NewRequest(tc.method, s.URL, nil)
+			require.NoError(t, err)
+			for k, v := range tc.header {
+				req.Header[k] = v
+			}
+			res, err := client.Do(req)
+			require.NoError(t, err)
+			require.NoError(t, res.Body.Close())
+			assert.Equal(t, tc.want, requests.Load())
+
+			spans := testTracer.FinishedSpans()
+			parent := spans[len(spans)-1]
+			if tc.want == 1 {
+				assert.Equal(t, datadogMiddleware.RetryOutcomeNotRetryable, parent.Tag(datadogMiddleware.TagRetryOutcome))
+			} else {
+				assert.Equal(t, datadogMiddleware.RetryOutcomeSuccess, parent.Tag(datadogMiddleware.TagRetryOutcome))
+			}
+		})
+	}
+}
+
+func TestAddRetriesToClientNetworkError(t *testing.T) {
+	testhelpers.ConfigureDatadog(t)
+	testTracer := mocktracer.Start()
+	t.Cleanup(testTracer.Stop)
+
+	s := httptest.NewServer(http.NotFoundHandler())
+	url := s.URL
+	s.Close()
+
+	var retried atomic.Int32
+	client := newRetryingClient(datadogMiddleware.RetryPolicy{
+		Backoff: noBackoff,
+		RetryError: func(error) bool {
+			retried.Add(1)
+			return true
+		},
+	})
+
+	_, err := client.Get(url)
+	require.Error(t, err)
+	assert.EqualValues(t, 3, retried.Load())
+
+	spans := testTracer.FinishedSpans()
+	require.Len(t, spans, 4)
+	parent := spans[3]
+	assert.Equal(t, datadogMiddleware.RetryOutcomeExhausted, parent.Tag(datadogMiddleware.TagRetryOutcome))
+	assert.NotNil(t, parent.Tag(ext.ErrorMsg))
+}
+
+func TestAddRetriesToClientCanceled(t *testing.T) {
+	testhelpers.ConfigureDatadog(t)
+	testTracer := mocktracer.Start()
+	t.Cleanup(testTracer.Stop)
+
+	s, requests := newFlakyServer(t, http.StatusServiceUnavailable)
+	client := newRetryingClient(datadogMiddleware.RetryPolicy{
+		Backoff: func(int) time.Duration { return time.Hour },
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	t.Cleanup(cancel)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.URL, nil)
+	require.NoError(t, err)
+	res, err := client.Do(req)
+	require.NoError(t, err)
+	require.NoError(t, res.Body.Close())
+	assert.Equal(t, http.StatusServiceUnavailable, res.StatusCode)
+	assert.EqualValues(t, 1, requests.Load())
+
+	spans := testTracer.FinishedSpans()
+	require.Len(t, spans, 2)
+	assert.Equal(t, datadogMiddleware.RetryOutcomeCanceled, spans[1].Tag(datadogMiddleware.TagRetryOutcome))
+}
+
+func TestAddRetriesToClientDatadogDisabled(t *testing.T) {
+	t.Setenv(internal.DatadogDisable, "true")
+
+	s, requests := newFlakyServer(t, http.StatusServiceUnavailable)
+	client := newRetryingClient(datadogMiddleware.RetryPolicy{Backoff: noBackoff})
+
+	res, err := client.Get(s.URL)
+	require.NoError(t, err)
+	require.NoError(t, res.Body.Close())
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	assert.EqualValues(t, 2, requests.Load())
+}
@@ -0,0 +1,68 @@
+package http //nolint:revive
+
+import (
+	"net/http"
+
+	httptrace "github.com/DataDog/dd-trace-go/contrib/net/http/v2"
+	"github.com/coopnorge/go-datadog-lib/v2/internal"
+)
+
+// router is implemented by http.ServeMux, and is used to find the pattern
+// of the route that will handle a request, before the request is handled.
+type router interface {
+	Handler(r *http.Request) (h http.Handler, pattern string)
+}
+
+// WrapHandler wraps the net/http.Handler to create a span for every incoming
+// request, and store it in the request's context. The span's ResourceName is
+// named with PatternResourceNamer by default. If the handler is an
+// http.ServeMux, the pattern of the route that will handle the request is
+// used, otherwise the pattern is only known if the handler is registered on an
+// http.ServeMux.
+func WrapHandler(handler http.Handler, options ...Option) http.Handler {
+	cfg := defaults()
+	for _, opt := range options {
+		opt(cfg)
+	}
+	if internal.IsDatadogDisabled() {
+		return cfg.recoverPanics(handler)
+	}
+	opts := convertServerOptions(options...)
+	traced := httptrace.WrapHandler(cfg.captureTags(cfg.recoverPanics(handler)), "", "", opts...)
+
+	r, ok := handler.(router)
+	if !ok {
+		return traced
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.Pattern == "" {
+			_, pattern := r.Handler(req)
+			req = req.WithContext(req.Context())
+			req.Pattern = pattern
+		}
+		traced.ServeHTTP(w, req)
+	})
+}
+
+// ServeMux is an http.ServeMux that creates a span for every incoming request.
+// Create a ServeMux with NewServeMux.
+type ServeMux struct {
+	*http.ServeMux
+	handler http.Handler
+}
+
+// NewServeMux creates a ServeMux, that creates a span for every incoming
+// request. See WrapHandler.
+func NewServeMux(options ...Option) *ServeMux {
+	mux := http.NewServeMux()
+	return &ServeMux{
+		ServeMux: mux,
+		handler:  WrapHandler(mux, options...),
+	}
+}
+
+// ServeHTTP creates a span for the request, and dispatches it to the handler
+// whose pattern most closely matches the request.
+func (mux *ServeMux) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	mux.handler.ServeHTTP(w, req)
+}
@@ -0,0 +1,179 @@
+package http_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/DataDog/dd-trace-go/v2/ddtrace/ext"
+	"github.com/DataDog/dd-trace-go/v2/ddtrace/mocktracer"
+	"github.com/coopnorge/go-datadog-lib/v2/internal"
+	"github.com/coopnorge/go-datadog-lib/v2/internal/testhelpers"
+	datadogMiddleware "github.com/coopnorge/go-datadog-lib/v2/middleware/http"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewServeMux(t *testing.T) {
+	testhelpers.ConfigureDatadog(t)
+	testTracer := mocktracer.Start()
+	t.Cleanup(testTracer.Stop)
+
+	mux := datadogMiddleware.NewServeMux(
+		datadogMiddleware.WithServiceName("my-service"),
+		datadogMiddleware.WithCustomTag("team", "checkout"),
+	)
+	mux.HandleFunc("GET /orders/{id}", func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	s := httptest.NewServer(mux)
+	t.Cleanup(s.Close)
+
+	res, err := http.Get(s.URL + "/orders/12345?email=pii@example.com")
+	require.NoError(t, err)
+	require.NoError(t, res.Body.Close())
+
+	spans := testTracer.FinishedSpans()
+	require.Len(t, spans, 1)
+	span := spans[0]
+	assert.Equal(t, "GET /orders/{id}", span.Tag(ext.ResourceName))
+	assert.Equal(t, "my-service", span.Tag(ext.ServiceName))
+	assert.Equal(t, "checkout", span.Tag("team"))
+	assert.Equal(t, "/orders/{id}", span.Tag(ext.HTTPRoute))
+	assert.Empty(t, span.Tag(ext.HTTPURL))
+	for tag, tagValue := range span.Tags() {
+		if str, ok := tagValue.(string); ok {
+			assert.NotContains(t, str, "12345", "Tag %q contains the raw path", tag)
+			assert.NotContains(t, str, "pii", "Tag %q contains the query", tag)
+		}
+	}
+}
+
+func TestWrapHandler(t *testing.T) {
+	testhelpers.ConfigureDatadog(t)
+	testTracer := mocktracer.Start()
+	t.Cleanup(testTracer.Stop)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/customers/{id}", func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	mux.Handle("POST /orders", datadogMiddleware.WrapHandler(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+	})))
+	handler := datadogMiddleware.WrapHandler(mux, datadogMiddleware.WithRequestIgnorer(func(req *http.Request) bool {
+		return req.URL.Path == "/healthz"
+	}))
+
+	tests := []struct {
+		method       string
+		path         string
+		wantResource string
+	}{
+		{http.MethodGet, "/customers/12345", "GET /customers/{id}"},
+		{http.MethodGet, "/does-not-exist/12345", "GET"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.path, func(t *testing.T) {
+			testTracer.Reset()
+			handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(tc.method, tc.path, nil))
+			spans := testTracer.FinishedSpans()
+			require.Len(t, spans, 1)
+			assert.Equal(t, tc.wantResource, spans[0].Tag(ext.ResourceName))
+		})
+	}
+
+	t.Run("Handler registered on a ServeMux", func(t *testing.T) {
+		testTracer.Reset()
+		mux.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/orders", nil))
+		spans := testTracer.FinishedSpans()
+		require.Len(t, spans, 1)
+		assert.Equal(t, "POST /orders", spans[0].Tag(ext.ResourceName))
+	})
+
+	t.Run("Ignored request", func(t *testing.T) {
+		testTracer.Reset()
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/healthz", nil))
+		assert.Empty(t, testTracer.FinishedSpans())
+	})
+}
+
+func TestWrapHandlerDatadogDisabled(t *testing.T) {
+	t.Setenv(internal.DatadogDisable, "true")
+
+	handler := http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {})
+	wrapped := datadogMiddleware.WrapHandler(handler)
+	assert.NotNil(t, wrapped)
+
+	mux := datadogMiddleware.NewServeMux()
+	mux.Handle("/", handler)
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+}
+
+func TestWrapHandlerWithErrorStatuses(t *testing.T) {
+	testhelpers.ConfigureDatadog(t)
+	testTracer := mocktracer.Start()
+	t.Cleanup(testTracer.Stop)
+
+	mux := datadogMiddleware.NewServeMux(datadogMiddleware.WithErrorStatuses(func(status int) bool {
+		return status == http.StatusTooManyRequests || status >= http.StatusInternalServerError
+	}))
+	mux.HandleFunc("/status/{code}", func(w http.ResponseWriter, r *http.Request) {
+		code, err := strconv.Atoi(r.PathValue("code"))
+		require.NoError(t, err)
+		w.WriteHeader(code)
+	})
+
+	for code, wantError := range map[int]bool{200: false, 404: false, 429: true, 503: true} {
+		t.Run(strconv.Itoa(code), func(t *testing.T) {
+			testTracer.Reset()
+			mux.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/status/"+strconv.Itoa(code), nil))
+			spans := testTracer.FinishedSpans()
+			require.Len(t, spans, 1)
+			assert.Equal(t, wantError, spans[0].Tag(ext.ErrorMsg) != nil)
+		})
+	}
+}
+
+func TestWrapHandlerWithHeaderAndQueryTags(t *testing.T) {
+	testhelpers.ConfigureDatadog(t)
+	testTracer := mocktracer.Start()
+	t.Cleanup(testTracer.Stop)
+
+	mux := datadogMiddleware.NewServeMux(
+		datadogMiddleware.WithRequestHeaderTags("X-Correlation-Id", "X-User-Id", "X-Missing"),
+		datadogMiddleware.WithResponseHeaderTags("X-Cache"),
+		datadogMiddleware.WithQueryParamAllowlist("page"),
+		datadogMiddleware.WithHashedTagValues("x-user-id"),
+	)
+	mux.HandleFunc("/orders", func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("X-Cache", "HIT")
+		w.WriteHeader(http.StatusOK)
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/orders?page=2&email=pii@example.com", nil)
+	req.Header.Set("X-Correlation-Id", "abc-123")
+	req.Header.Set("X-User-Id", "user-42")
+	req.Header.Set("Authorization", "Bearer secret")
+	mux.ServeHTTP(httptest.NewRecorder(), req)
+
+	spans := testTracer.FinishedSpans()
+	require.Len(t, spans, 1)
+	span := spans[0]
+	assert.Equal(t, "abc-123", span.Tag("http.request.headers.x-correlation-id"))
+	assert.Equal(t, internal.HashTagValue("user-42"), span.Tag("http.request.headers.x-user-id"))
+	assert.Nil(t, span.Tag("http.request.headers.x-missing"))
+	assert.Equal(t, "HIT", span.Tag("http.response.headers.x-cache"))
+	assert.Equal(t, "2", span.Tag("http.query.page"))
+	for tag, tagValue := range span.Tags() {
+		if str, ok := tagValue.(string); ok {
+			assert.NotContains(t, str, "pii", "Tag %q contains a query parameter that is not allowlisted", tag)
+			assert.NotContains(t, str, "secret", "Tag %q contains a header that is not allowlisted", tag)
+			assert.NotContains(t, str, "user-42", "Tag %q contains a hashed value", tag)
+		}
+	}
+}
@@ -0,0 +1,175 @@
+package http //nolint:revive
+
+import (
+	"net/http"
+	"regexp"
+	"strings"
+)
+
+// Placeholders that TemplatedPathResourceNamer replaces identifiers with.
+const (
+	PlaceholderUUID       = "{uuid}"
+	PlaceholderID         = "{id}"
+	PlaceholderNationalID = "{nin}"
+	PlaceholderEmail      = "{email}"
+	PlaceholderToken      = "{token}"
+)
+
+// minTokenLength is the minimum length of a path segment of only hexadecimal
+// characters for it to be considered a token.
+const minTokenLength = 16
+
+var (
+	uuidPattern  = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+	emailPattern = regexp.MustCompile(`^[^@\s]+@[^@\s]+\.[^@\s]+$`)
+)
+
+type pathRule struct {
+	pattern     *regexp.Regexp
+	placeholder string
+}
+
+type templateConfig struct {
+	rules  []pathRule
+	routes *http.ServeMux
+}
+
+// TemplateOption configures TemplatedPathResourceNamer.
+type TemplateOption func(cfg *templateConfig)
+
+// WithPathRule replaces every path segment that pattern matches with
+// placeholder. Rules are applied in the order they are given, before the
+// built-in rules. Anchor the pattern with ^ and $ to match whole segments
+// only.
+func WithPathRule(pattern *regexp.Regexp, placeholder string) TemplateOption {
+	return func(cfg *templateConfig) {
+		cfg.rules = append(cfg.rules, pathRule{pattern: pattern, placeholder: placeholder})
+	}
+}
+
+// WithRouteTable sets the known routes of the API, on the pattern syntax of
+// http.ServeMux, such as "/orders/{id}/lines/{line}". Requests that match a
+// route are named by the route, instead of by replacing the identifiers in
+// the path. Like http.ServeMux, WithRouteTable panics if the routes conflict.
+func WithRouteTable(routes ...string) TemplateOption {
+	return func(cfg *templateConfig) {
+		if cfg.routes == nil {
+			cfg.routes = http.NewServeMux()
+		}
+		for _, route := range routes {
+			cfg.routes.Handle(route, http.NotFoundHandler())
+		}
+	}
+}
+
+// TemplatedPathResourceNamer will name the ResourceName to "GET https://www.coop.no/api/orders/{id}",
+// by replacing the path segments that look like identifiers with placeholders:
+// UUIDs with {uuid}, Norwegian national identity numbers with {nin}, other
+// numbers with {id}, email addresses with {email}, and hexadecimal strings of
+// at least 16 characters with {token}. The query is never part of the
+// ResourceName.
+func TemplatedPathResourceNamer(options ...TemplateOption) ResourceNamer {
+	cfg := &templateConfig{}
+	for _, opt := range options {
+		opt(cfg)
+	}
+	return func(req *http.Request) string {
+		u := req.URL
+		prefix := req.Method + " "
+		if u.Host != "" {
+			prefix += u.Scheme + "://" + u.Host
+		}
+		return prefix + cfg.templatePath(req)
+	}
+}
+
+func (cfg *templateConfig) templatePath(req *http.Request) string {
+	if cfg.routes != nil {
+		if _, pattern := cfg.routes.Handler(req); pattern != "" {
+			if _, path, ok := strings.Cut(pattern, " "); ok {
+				// The method is part of the pattern.
+				pattern = strings.TrimSpace(path)
+			}
+			if !strings.HasPrefix(pattern, "/") {
+				// The host is part of the pattern, and already in the prefix.
+				pattern = pattern[strings.Index(pattern, "/"):]
+			}
+			return pattern
+		}
+	}
+
+	segments := strings.Split(req.URL.Path, "/")
+	for i, segment := range segments {
+		if segment != "" {
+			segments[i] = cfg.templateSegment(segment)
+		}
+	}
+	return strings.Join(segments, "/")
+}
+
+func (cfg *templateConfig) templateSegment(segment string) string {
+	for _, rule := range cfg.rules {
+		if rule.pattern.MatchString(segment) {
+			return rule.placeholder
+		}
+	}
+	switch {
+	case uuidPattern.MatchString(segment):
+		return PlaceholderUUID
+	case isDigits(segment):
+		if isNationalID(segment) {
+			return PlaceholderNationalID
+		}
+		return PlaceholderID
+	case emailPattern.MatchString(segment):
+		return PlaceholderEmail
+	case len(segment) >= minTokenLength && isHex(segment):
+		return PlaceholderToken
+	}
+	return segment
+}
+
+func isDigits(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+func isHex(s string) bool {
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if (c < '0' || c > '9') && (c < 'a' || c > 'f') && (c < 'A' || c > 'F') {
+			return false
+		}
+	}
+	return true
+}
+
+// isNationalID reports whether s is a Norwegian national identity number
+// (fødselsnummer or D-number), by validating its two check digits.
+func isNationalID(s string) bool {
+	if len(s) != 11 {
+		return false
+	}
+	d := make([]int, 11)
+	for i := range s {
+		d[i] = int(s[i] - '0')
+	}
+	checkDigit := func(weights []int) int {
+		sum := 0
+		for i, w := range weights {
+			sum += d[i] * w
+		}
+		k := 11 - sum%11
+		if k == 11 {
+			k = 0
+		}
+		return k
+	}
+	k1 := checkDigit([]int{3, 7, 6, 1, 8, 9, 4, 5, 2})
+	k2 := checkDigit([]int{5, 4, 3, 2, 7, 6, 5, 4, 3, 2})
+	return k1 == d[9] && k2 == d[10]
+}
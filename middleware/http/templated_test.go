@@ -0,0 +1,85 @@
+package http_test
+
+import (
+	"net/http"
+	"regexp"
+	"testing"
+
+	datadogMiddleware "github.com/coopnorge/go-datadog-lib/v2/middleware/http"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTemplatedPathResourceNamer(t *testing.T) {
+	rn := datadogMiddleware.TemplatedPathResourceNamer()
+
+	testCases := []struct {
+		name     string
+		url      string
+		expected string
+	}{
+		{"No identifiers", "https://www.coop.no/api/orders", "GET https://www.coop.no/api/orders"},
+		{"Numeric ID", "https://www.coop.no/api/orders/12345/lines/2", "GET https://www.coop.no/api/orders/{id}/lines/{id}"},
+		{"UUID", "https://www.coop.no/api/carts/3f2504e0-4f89-11d3-9a0c-0305e82c3301", "GET https://www.coop.no/api/carts/{uuid}"},
+		{"National ID", "https://www.coop.no/api/members/15019012317", "GET https://www.coop.no/api/members/{nin}"},
+		{"Eleven digits that are not a national ID", "https://www.coop.no/api/members/15019012318", "GET https://www.coop.no/api/members/{id}"},
+		{"Email", "https://www.coop.no/api/users/ola.nordmann@example.com/orders", "GET https://www.coop.no/api/users/{email}/orders"},
+		{"Hex token", "https://www.coop.no/api/reset/9f86d081884c7d659a2feaa0c55ad015", "GET https://www.coop.no/api/reset/{token}"},
+		{"Short hex word", "https://www.coop.no/api/cafe/decade", "GET https://www.coop.no/api/cafe/decade"},
+		{"Query is removed", "https://www.coop.no/api/orders/1?email=ola@example.com", "GET https://www.coop.no/api/orders/{id}"},
+		{"Trailing slash", "https://www.coop.no/api/orders/1/", "GET https://www.coop.no/api/orders/{id}/"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req, err := http.NewRequest("GET", tc.url, nil)
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, rn(req))
+		})
+	}
+}
+
+func TestTemplatedPathResourceNamerServerRequest(t *testing.T) {
+	rn := datadogMiddleware.TemplatedPathResourceNamer()
+
+	req, err := http.NewRequest("GET", "/api/orders/12345", nil)
+	require.NoError(t, err)
+	assert.Equal(t, "GET /api/orders/{id}", rn(req))
+}
+
+func TestTemplatedPathResourceNamerWithPathRule(t *testing.T) {
+	rn := datadogMiddleware.TemplatedPathResourceNamer(
+		datadogMiddleware.WithPathRule(regexp.MustCompile(`^ORD-[0-9]+$`), "{order}"),
+		// User rules take precedence over the built-in rules.
+		datadogMiddleware.WithPathRule(regexp.MustCompile(`^7[0-9]{3}$`), "{store}"),
+	)
+
+	req, err := http.NewRequest("GET", "https://www.coop.no/api/stores/7001/orders/ORD-123", nil)
+	require.NoError(t, err)
+	assert.Equal(t, "GET https://www.coop.no/api/stores/{store}/orders/{order}", rn(req))
+}
+
+func TestTemplatedPathResourceNamerWithRouteTable(t *testing.T) {
+	rn := datadogMiddleware.TemplatedPathResourceNamer(
+		datadogMiddleware.WithRouteTable(
+			"/api/products/{sku}",
+			"GET www.coop.no/api/stores/{store}/opening-hours",
+		),
+	)
+
+	testCases := []struct {
+		url      string
+		expected string
+	}{
+		{"https://www.coop.no/api/products/ABC-123", "GET https://www.coop.no/api/products/{sku}"},
+		{"https://www.coop.no/api/stores/oslo-sentrum/opening-hours", "GET https://www.coop.no/api/stores/{store}/opening-hours"},
+		// Requests that do not match a route fall back to the built-in rules.
+		{"https://www.coop.no/api/orders/12345", "GET https://www.coop.no/api/orders/{id}"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.url, func(t *testing.T) {
+			req, err := http.NewRequest("GET", tc.url, nil)
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, rn(req))
+		})
+	}
+}